@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package asn
+
+import (
+	"net"
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/lookup"
+)
+
+type asyncResolver struct {
+	cache *lookup.Cache[int]
+}
+
+// NewAsyncResolver returns a resolver caching the results of r (including addresses not announced). Addresses not cached
+// are resolved in the background, `lookup.ErrPending` is returned until the lookup completed. Failed lookups are cached
+// for `lookup.DefaultNegativeTTL`.
+func NewAsyncResolver(r Resolver, ttl time.Duration) Resolver {
+	return &asyncResolver{
+		cache: lookup.NewCache(r.ASN, ttl, lookup.DefaultNegativeTTL),
+	}
+}
+
+// ASN returns the origin ASN for the IP address
+func (r *asyncResolver) ASN(ip net.IP) (int, error) {
+	return r.cache.Get(ip)
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package asn
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// recordReader looks up the record of an IP address in a MaxMind database
+type recordReader interface {
+	Lookup(ip net.IP, result interface{}) error
+}
+
+type maxMindResolver struct {
+	reader recordReader
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+}
+
+// NewMaxMindResolver returns a resolver using a local MaxMind ASN database (e.g. GeoLite2-ASN.mmdb)
+func NewMaxMindResolver(path string) (Resolver, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &maxMindResolver{reader: r}, nil
+}
+
+// ASN returns the origin ASN for the IP address
+func (r *maxMindResolver) ASN(ip net.IP) (int, error) {
+	rec := &asnRecord{}
+	err := r.reader.Lookup(ip, rec)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rec.AutonomousSystemNumber), nil
+}
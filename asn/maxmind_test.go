@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package asn
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRecords map[string]uint
+
+func (r staticRecords) Lookup(ip net.IP, result interface{}) error {
+	if ip.String() == "192.0.2.66" {
+		return errors.New("invalid database")
+	}
+
+	result.(*asnRecord).AutonomousSystemNumber = r[ip.String()]
+	return nil
+}
+
+func TestMaxMindResolver(t *testing.T) {
+	r := &maxMindResolver{reader: staticRecords{"193.0.0.1": 3333}}
+
+	a, err := r.ASN(net.ParseIP("193.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3333, a)
+
+	a, err = r.ASN(net.ParseIP("198.51.100.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, a, "address not announced")
+
+	_, err = r.ASN(net.ParseIP("192.0.2.66"))
+	assert.Error(t, err)
+}
+
+func TestNewMaxMindResolverMissingFile(t *testing.T) {
+	_, err := NewMaxMindResolver("testdata/does-not-exist.mmdb")
+	assert.Error(t, err)
+}
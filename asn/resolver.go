@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package asn
+
+import (
+	"net"
+)
+
+// Resolver resolves the origin ASN of an IP address
+type Resolver interface {
+	// ASN returns the origin ASN for the IP address (0 if the address is not announced)
+	ASN(ip net.IP) (int, error)
+}
+
+// IsPublic returns whether an IP address can be resolved to an ASN (e.g. no private or link local addresses)
+func IsPublic(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package asn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	ripeStatURL     = "https://stat.ripe.net/data/network-info/data.json"
+	ripeStatTimeout = 10 * time.Second
+)
+
+type ripeStatResolver struct {
+	client *http.Client
+}
+
+type networkInfo struct {
+	Data struct {
+		ASNs   []string `json:"asns"`
+		Prefix string   `json:"prefix"`
+	} `json:"data"`
+}
+
+// NewRIPEstatResolver returns a resolver using the network-info endpoint of the RIPEstat Data API
+func NewRIPEstatResolver() Resolver {
+	return &ripeStatResolver{
+		client: &http.Client{Timeout: ripeStatTimeout},
+	}
+}
+
+// ASN returns the origin ASN for the IP address
+func (r *ripeStatResolver) ASN(ip net.IP) (int, error) {
+	u := fmt.Sprintf("%s?resource=%s&sourceapp=atlas_exporter", ripeStatURL, ip.String())
+
+	resp, err := r.client.Get(u)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("could not resolve ASN for %s: %s", ip, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	return asnFromNetworkInfo(body)
+}
+
+func asnFromNetworkInfo(b []byte) (int, error) {
+	info := &networkInfo{}
+	err := json.Unmarshal(b, info)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(info.Data.ASNs) == 0 {
+		return 0, nil
+	}
+
+	return strconv.Atoi(info.Data.ASNs[0])
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package atlas
+
+import (
+	"fmt"
+
+	"github.com/DNS-OARC/ripeatlas"
+	"github.com/czerwonk/atlas_exporter/config"
+)
+
+// ValidateMeasurements checks that all configured measurements exist and are of a supported type
+func ValidateMeasurements(cfg *config.Config) []error {
+	a := ripeatlas.Atlaser(ripeatlas.NewHttp())
+
+	errs := make([]error, 0)
+	for _, m := range cfg.Measurements {
+		if err := validateMeasurement(a, m.ID, cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func validateMeasurement(a ripeatlas.Atlaser, id string, cfg *config.Config) error {
+	ch, err := a.Measurements(ripeatlas.Params{"pk": id})
+	if err != nil {
+		return fmt.Errorf("could not retrieve measurement %s: %v", id, err)
+	}
+
+	for msm := range ch {
+		if msm.ParseError != nil {
+			return fmt.Errorf("could not parse measurement %s: %v", id, msm.ParseError)
+		}
+
+		if msm.Id() == 0 {
+			return fmt.Errorf("measurement %s does not exist", id)
+		}
+
+		if _, err := measurementForType(msm.Type(), id, "", cfg); err != nil {
+			return fmt.Errorf("measurement %s: %v", id, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("measurement %s does not exist", id)
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package atlas
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubAtlaser returns the measurements from a fixed set of API responses
+type stubAtlaser struct {
+	ripeatlas.Atlaser
+	measurements map[string]string
+	err          error
+}
+
+func (a *stubAtlaser) Measurements(p ripeatlas.Params) (<-chan *ripeatlas.Measurement, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	ch := make(chan *ripeatlas.Measurement, 1)
+	defer close(ch)
+
+	s, found := a.measurements[p["pk"].(string)]
+	if !found {
+		return ch, nil
+	}
+
+	msm := &ripeatlas.Measurement{}
+	if err := json.Unmarshal([]byte(s), msm); err != nil {
+		msm.ParseError = err
+	}
+	ch <- msm
+
+	return ch, nil
+}
+
+func TestValidateMeasurement(t *testing.T) {
+	a := &stubAtlaser{
+		measurements: map[string]string{
+			"1001": `{"id": 1001, "af": 4, "type": "ping", "description": "Ping measurement to 8.8.8.8", "target": "8.8.8.8", "interval": 240, "is_oneoff": false, "is_public": true, "status": {"id": 2, "name": "Ongoing"}}`,
+			"1002": `{"id": 1002, "af": 4, "type": "wifi", "description": "WiFi measurement", "interval": 900, "is_oneoff": false, "is_public": true, "status": {"id": 2, "name": "Ongoing"}}`,
+			"1003": `{"detail": "Not found."}`,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		atlaser  ripeatlas.Atlaser
+		id       string
+		expected string
+	}{
+		{name: "supported type", atlaser: a, id: "1001"},
+		{name: "unsupported type", atlaser: a, id: "1002", expected: "measurement 1002: type wifi is not supported yet"},
+		{name: "unknown measurement", atlaser: a, id: "1003", expected: "measurement 1003 does not exist"},
+		{name: "no response", atlaser: a, id: "9999", expected: "measurement 9999 does not exist"},
+		{name: "API error", atlaser: &stubAtlaser{err: errors.New("403 Forbidden")}, id: "1001", expected: "could not retrieve measurement 1001: 403 Forbidden"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			err := validateMeasurement(test.atlaser, test.id, &config.Config{})
+			if test.expected == "" {
+				assert.NoError(te, err)
+				return
+			}
+
+			assert.EqualError(te, err, test.expected)
+		})
+	}
+}
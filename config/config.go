@@ -3,20 +3,172 @@
 package config
 
 import (
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
 )
 
+const defaultCoordinatePrecision = 4
+
 // Config represents the configuration for the exporter
 type Config struct {
 	// Measurements is the ids of measurements used as source for metrics generation
 	Measurements         []Measurement    `yaml:"measurements"`
 	HistogramBuckets     HistogramBuckets `yaml:"histogram_buckets"`
 	FilterInvalidResults bool             `yaml:"filter_invalid_results"`
+	MinFirmware          int              `yaml:"min_firmware,omitempty"`
+	CoordinatePrecision  *int             `yaml:"coordinate_precision,omitempty"`
+	FailureThreshold     int              `yaml:"failure_threshold,omitempty"`
+	ProbePrefixLabel     bool             `yaml:"probe_prefix_label,omitempty"`
+	SrcAddrLabel         bool             `yaml:"src_addr_label,omitempty"`
+	DropLabels           []string         `yaml:"drop_labels,omitempty"`
+	UpMetric             bool             `yaml:"up_metric,omitempty"`
+	DNS                  DNSConfig        `yaml:"dns,omitempty"`
+	SSLCert              SSLCertConfig    `yaml:"sslcert,omitempty"`
+	Traceroute           TracerouteConfig `yaml:"traceroute,omitempty"`
+	Ping                 PingConfig       `yaml:"ping,omitempty"`
+}
+
+// DNSConfig defines options for DNS measurements
+type DNSConfig struct {
+	// AnswerMode defines how answers are exported: one series per answer ("answer", default),
+	// one series per query name with all answers joined ("set") or only the number and a hash of the answers per query name ("count")
+	AnswerMode string `yaml:"answer_mode,omitempty"`
+
+	// NSIDLabel adds the name server identifier (NSID) of the response as label to the success and rtt metrics
+	NSIDLabel bool `yaml:"nsid_label,omitempty"`
+
+	// QueryLabels adds the name and type of the query as labels to the success, error and rtt metrics
+	QueryLabels bool `yaml:"query_labels,omitempty"`
+
+	// ResultsetLabel adds the index of the result set as label to all metrics (to distinguish multiple queries to the same resolver)
+	ResultsetLabel bool `yaml:"resultset_label,omitempty"`
+
+	// ProtoLabel adds the transport protocol of the query (UDP or TCP) as label to all metrics
+	ProtoLabel bool `yaml:"proto_label,omitempty"`
+
+	// DualStack enables comparison of the response codes of queries for the same name via IPv4 and IPv6 (across measurements)
+	DualStack bool `yaml:"dualstack,omitempty"`
+}
+
+// SSLCertConfig defines options for sslcert measurements
+type SSLCertConfig struct {
+	// TLSVersionLabel adds the negotiated SSL/TLS version as label to the success metric
+	TLSVersionLabel bool `yaml:"tls_version_label,omitempty"`
+
+	// IssuerDN uses the full distinguished name of the issuer as issuer label instead of the organization (or common name)
+	IssuerDN bool `yaml:"issuer_dn,omitempty"`
+
+	// UnknownIssuer is used as issuer label when the issuer could not be extracted from the certificate
+	UnknownIssuer string `yaml:"unknown_issuer,omitempty"`
+
+	// FingerprintChange enables tracking of leaf certificate fingerprint changes per measurement and probe
+	FingerprintChange bool `yaml:"fingerprint_change,omitempty"`
+
+	// SubjectLabels adds the subject common name and the DNS names of the leaf certificate as labels to all metrics
+	SubjectLabels bool `yaml:"subject_labels,omitempty"`
+
+	// DstNameLabel adds the target name of the measurement (SNI) as label to all metrics
+	DstNameLabel bool `yaml:"dst_name_label,omitempty"`
+
+	// DstPortLabel adds the target port of the measurement as label to all metrics
+	DstPortLabel bool `yaml:"dst_port_label,omitempty"`
+
+	// LegacyFingerprints adds the SHA-1 and MD5 fingerprints of the leaf certificate as labels to all metrics
+	LegacyFingerprints bool `yaml:"legacy_fingerprints,omitempty"`
+
+	// AlertLabels exports alerts sent by the server as atlas_sslcert_alert with the names of level and description as labels
+	// instead of the numeric atlas_sslcert_alert_level and atlas_sslcert_alert_description metrics
+	AlertLabels bool `yaml:"alert_labels,omitempty"`
+
+	// LegacyVersionMetric enables the atlas_sslcert_version metric with the version reported by the probe as value (e.g. 3.3)
+	LegacyVersionMetric bool `yaml:"legacy_version_metric,omitempty"`
+
+	// VerifyChain enables verification of the served certificate chains against the system roots or the roots of RootCAFile
+	VerifyChain bool   `yaml:"verify_chain,omitempty"`
+	RootCAFile  string `yaml:"root_ca_file,omitempty"`
+
+	rootCAs *x509.CertPool
+}
+
+// TracerouteConfig defines options for traceroute measurements
+type TracerouteConfig struct {
+	// ASPath enables export of the AS path derived from the hop addresses (requires IP to ASN lookups)
+	ASPath bool `yaml:"as_path,omitempty"`
+
+	// ASNDatabase is the path of a MaxMind ASN database (e.g. GeoLite2-ASN.mmdb) used for the IP to ASN lookups
+	// instead of the RIPEstat Data API
+	ASNDatabase string `yaml:"asn_database,omitempty"`
+
+	// ParisIDLabel adds the Paris ID (flow identifier) of the result as label to all metrics
+	ParisIDLabel bool `yaml:"paris_id_label,omitempty"`
+
+	// HopCountries enables export of the number of hops per country (requires IP geolocation lookups)
+	HopCountries bool `yaml:"hop_countries,omitempty"`
+
+	// HopCities enables export of the number of hops per city (requires IP geolocation lookups)
+	HopCities bool `yaml:"hop_cities,omitempty"`
+
+	// GeoDatabase is the path of a MaxMind city or country database (e.g. GeoLite2-City.mmdb) used for the IP geolocation lookups
+	// instead of the RIPEstat Data API
+	GeoDatabase string `yaml:"geo_database,omitempty"`
+
+	// HopRTT enables export of the RTT of each responding hop
+	HopRTT bool `yaml:"hop_rtt,omitempty"`
+
+	// HopLoss enables export of the ratio of packets without reply per hop
+	HopLoss bool `yaml:"hop_loss,omitempty"`
+
+	// MPLS enables export of the MPLS label stacks reported in the ICMP extensions of the hop replies (RFC 4950)
+	MPLS bool `yaml:"mpls,omitempty"`
+
+	// PathChanges enables counting of path changes per measurement and probe
+	PathChanges bool `yaml:"path_changes,omitempty"`
+}
+
+// PingConfig defines options for ping measurements
+type PingConfig struct {
+	// PacketRTT enables export of the RTT of each reply
+	PacketRTT bool `yaml:"packet_rtt,omitempty"`
+
+	// SizeLabel adds the packet size of the result as label to all metrics
+	SizeLabel bool `yaml:"size_label,omitempty"`
+}
+
+// UnknownIssuerValue returns the value used as issuer label when the issuer could not be extracted (default: unknown)
+func (c *SSLCertConfig) UnknownIssuerValue() string {
+	if c.UnknownIssuer == "" {
+		return "unknown"
+	}
+
+	return c.UnknownIssuer
+}
+
+// RootCAs returns the roots of RootCAFile loaded when validating the config (nil if the system roots are used)
+func (c *SSLCertConfig) RootCAs() *x509.CertPool {
+	return c.rootCAs
+}
+
+func (c *SSLCertConfig) loadRootCAs() error {
+	b, err := os.ReadFile(c.RootCAFile)
+	if err != nil {
+		return fmt.Errorf("could not read root CA file: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(b) {
+		return fmt.Errorf("no certificates found in root CA file %s", c.RootCAFile)
+	}
+	c.rootCAs = roots
+
+	return nil
 }
 
 // HistogramBuckets defines buckets for several histograms
@@ -30,12 +182,50 @@ type HistogramBuckets struct {
 // RttHistogramBucket defines buckets for RTT histograms
 type RttHistogramBucket struct {
 	Rtt []float64 `yaml:"rtt"`
+
+	// Hops defines buckets for the hop count histogram (only used for traceroute)
+	Hops []float64 `yaml:"hops,omitempty"`
 }
 
 // Measurement represents config options for one measurement
 type Measurement struct {
-	ID      string        `yaml:"id"`
-	Timeout time.Duration `yaml:"timeout,omitempty"`
+	ID              string           `yaml:"id"`
+	Timeout         time.Duration    `yaml:"timeout,omitempty"`
+	TLSA            *TLSA            `yaml:"tlsa,omitempty"`
+	ExpectedAnswers *ExpectedAnswers `yaml:"expected_answers,omitempty"`
+
+	// Fingerprints are the expected SHA-256 fingerprints of the leaf certificate of SSL measurements (pinning)
+	Fingerprints []string `yaml:"fingerprints,omitempty"`
+}
+
+// ExpectedAnswers represents the expected answers of DNS measurements (values are matched exactly, e.g. IP addresses, or by regular expression)
+type ExpectedAnswers struct {
+	Values  []string `yaml:"values,omitempty"`
+	Pattern string   `yaml:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Regexp returns the pattern compiled when validating the config (nil if no pattern is configured)
+func (e *ExpectedAnswers) Regexp() *regexp.Regexp {
+	return e.pattern
+}
+
+// TLSA represents an expected TLSA record used to verify the certificates served in SSL measurements (DANE)
+type TLSA struct {
+	Usage        uint8  `yaml:"usage"`
+	Selector     uint8  `yaml:"selector"`
+	MatchingType uint8  `yaml:"matching_type"`
+	Data         string `yaml:"data"`
+}
+
+// LatLongPrecision returns the number of decimals used for lat/long labels
+func (c *Config) LatLongPrecision() int {
+	if c.CoordinatePrecision == nil || *c.CoordinatePrecision < 0 {
+		return defaultCoordinatePrecision
+	}
+
+	return *c.CoordinatePrecision
 }
 
 // MeasurementIDs represents all IDs of configured measurements
@@ -48,6 +238,64 @@ func (c *Config) MeasurementIDs() []string {
 	return ids
 }
 
+// MeasurementByID returns the config options for the measurement with the given ID (nil if not configured)
+func (c *Config) MeasurementByID(id string) *Measurement {
+	for i := range c.Measurements {
+		if c.Measurements[i].ID == id {
+			return &c.Measurements[i]
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the config for invalid values and prepares values used by the exporters (e.g. compiles patterns and loads the root CA file)
+func (c *Config) Validate() []error {
+	errs := make([]error, 0)
+
+	ids := make(map[string]bool)
+	for _, m := range c.Measurements {
+		if _, err := strconv.Atoi(m.ID); err != nil {
+			errs = append(errs, fmt.Errorf("invalid measurement id: %q", m.ID))
+		}
+
+		if m.ExpectedAnswers != nil && m.ExpectedAnswers.Pattern != "" {
+			p, err := regexp.Compile(m.ExpectedAnswers.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid expected answer pattern for measurement %s: %v", m.ID, err))
+			}
+			m.ExpectedAnswers.pattern = p
+		}
+
+		if ids[m.ID] {
+			errs = append(errs, fmt.Errorf("measurement %s is configured more than once", m.ID))
+		}
+		ids[m.ID] = true
+	}
+
+	if c.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("invalid failure threshold: %d", c.FailureThreshold))
+	}
+
+	if c.MinFirmware < 0 {
+		errs = append(errs, fmt.Errorf("invalid minimum firmware: %d", c.MinFirmware))
+	}
+
+	if c.SSLCert.VerifyChain && c.SSLCert.RootCAFile != "" {
+		if err := c.SSLCert.loadRootCAs(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch c.DNS.AnswerMode {
+	case "", "answer", "set", "count":
+	default:
+		errs = append(errs, fmt.Errorf("invalid DNS answer mode: %q", c.DNS.AnswerMode))
+	}
+
+	return errs
+}
+
 // Load loads a config from a reader
 func Load(r io.Reader) (*Config, error) {
 	b, err := ioutil.ReadAll(r)
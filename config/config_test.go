@@ -3,6 +3,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -91,6 +93,56 @@ measurements:
 				FilterInvalidResults: true,
 			},
 		},
+		{
+			name: "valid config with tlsa",
+			value: `
+measurements:
+  - id: 123
+    tlsa:
+      usage: 3
+      selector: 1
+      matching_type: 1
+      data: abcdef`,
+			expected: Config{
+				Measurements: []Measurement{
+					{ID: "123", TLSA: &TLSA{Usage: 3, Selector: 1, MatchingType: 1, Data: "abcdef"}},
+				},
+				FilterInvalidResults: true,
+			},
+		},
+		{
+			name: "valid config with sslcert options",
+			value: `
+sslcert:
+  tls_version_label: true`,
+			expected: Config{
+				SSLCert: SSLCertConfig{
+					TLSVersionLabel: true,
+				},
+				FilterInvalidResults: true,
+			},
+		},
+		{
+			name: "valid config with dns options",
+			value: `
+dns:
+  answer_mode: set`,
+			expected: Config{
+				DNS: DNSConfig{
+					AnswerMode: "set",
+				},
+				FilterInvalidResults: true,
+			},
+		},
+		{
+			name: "valid config with coordinate precision",
+			value: `
+coordinate_precision: 2`,
+			expected: Config{
+				CoordinatePrecision:  intPtr(2),
+				FilterInvalidResults: true,
+			},
+		},
 		{
 			name: "valid config with filter override",
 			value: `
@@ -122,3 +174,84 @@ filter_invalid_results: false`,
 		})
 	}
 }
+
+func TestLatLongPrecision(t *testing.T) {
+	assert.Equal(t, 4, (&Config{}).LatLongPrecision())
+	assert.Equal(t, 0, (&Config{CoordinatePrecision: intPtr(0)}).LatLongPrecision())
+	assert.Equal(t, 2, (&Config{CoordinatePrecision: intPtr(2)}).LatLongPrecision())
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		errors int
+	}{
+		{
+			name: "valid config",
+			config: Config{
+				Measurements: []Measurement{{ID: "123"}, {ID: "456"}},
+			},
+		},
+		{
+			name: "invalid measurement id",
+			config: Config{
+				Measurements: []Measurement{{ID: "abc"}},
+			},
+			errors: 1,
+		},
+		{
+			name: "invalid expected answer pattern",
+			config: Config{
+				Measurements: []Measurement{{ID: "123", ExpectedAnswers: &ExpectedAnswers{Pattern: "("}}},
+			},
+			errors: 1,
+		},
+		{
+			name: "missing root CA file",
+			config: Config{
+				SSLCert: SSLCertConfig{VerifyChain: true, RootCAFile: "/nonexistent/roots.pem"},
+			},
+			errors: 1,
+		},
+		{
+			name: "duplicate measurement and invalid answer mode",
+			config: Config{
+				Measurements: []Measurement{{ID: "123"}, {ID: "123"}},
+				DNS:          DNSConfig{AnswerMode: "foo"},
+			},
+			errors: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			assert.Len(te, test.config.Validate(), test.errors)
+		})
+	}
+}
+
+func TestValidateRootCAFileWithoutCertificates(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(file, []byte("no certificates"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{SSLCert: SSLCertConfig{VerifyChain: true, RootCAFile: file}}
+	assert.Len(t, c.Validate(), 1)
+	assert.Nil(t, c.SSLCert.RootCAs())
+}
+
+func TestValidateCompilesExpectedAnswerPattern(t *testing.T) {
+	c := &Config{Measurements: []Measurement{{ID: "123", ExpectedAnswers: &ExpectedAnswers{Pattern: `^192\.0\.2\.`}}}}
+	assert.Empty(t, c.Validate())
+
+	p := c.MeasurementByID("123").ExpectedAnswers.Regexp()
+	if assert.NotNil(t, p) {
+		assert.True(t, p.MatchString("192.0.2.1"))
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"net"
+	"regexp"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	mdns "github.com/miekg/dns"
+)
+
+// answerMatcher checks the answers of a response against the expected answers of a measurement
+type answerMatcher struct {
+	values  map[string]bool
+	pattern *regexp.Regexp
+}
+
+func newAnswerMatcher(expected *config.ExpectedAnswers) *answerMatcher {
+	m := &answerMatcher{
+		values:  make(map[string]bool),
+		pattern: expected.Regexp(),
+	}
+
+	for _, v := range expected.Values {
+		m.values[normalizeAnswer(v)] = true
+	}
+
+	return m
+}
+
+// matches returns true if the response contains answers and all of them are expected (CNAME records of the chain are ignored)
+func (m *answerMatcher) matches(msg *mdns.Msg) bool {
+	found := false
+	for _, ans := range msg.Answer {
+		rrType, value, ok := answerValue(ans)
+		if !ok || rrType == "CNAME" {
+			continue
+		}
+
+		if !m.matchesValue(value) {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+func (m *answerMatcher) matchesValue(value string) bool {
+	if m.values[normalizeAnswer(value)] {
+		return true
+	}
+
+	return m.pattern != nil && m.pattern.MatchString(value)
+}
+
+// normalizeAnswer returns IP addresses in canonical form so differently formatted IPv6 addresses are equal
+func normalizeAnswer(value string) string {
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+
+	return value
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	mdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  []string
+		expected bool
+	}{
+		{
+			name:     "expected value",
+			answers:  []string{"example.com. 300 IN A 192.0.2.1"},
+			expected: true,
+		},
+		{
+			name:     "expected IPv6 value in other notation",
+			answers:  []string{"example.com. 300 IN AAAA 2001:db8:0:0::1"},
+			expected: true,
+		},
+		{
+			name:     "pattern",
+			answers:  []string{"example.com. 300 IN A 192.0.2.1", "example.com. 300 IN A 198.51.100.7"},
+			expected: true,
+		},
+		{
+			name:     "CNAME is ignored",
+			answers:  []string{"www.example.com. 300 IN CNAME example.com.", "example.com. 300 IN A 192.0.2.1"},
+			expected: true,
+		},
+		{
+			name:     "unexpected value",
+			answers:  []string{"example.com. 300 IN A 192.0.2.1", "example.com. 300 IN A 203.0.113.1"},
+			expected: false,
+		},
+		{
+			name:     "no answers",
+			expected: false,
+		},
+	}
+
+	cfg := &config.Config{Measurements: []config.Measurement{{
+		ID:              "1",
+		ExpectedAnswers: &config.ExpectedAnswers{Values: []string{"192.0.2.1", "2001:db8::1"}, Pattern: `^198\.51\.100\.`},
+	}}}
+	if errs := cfg.Validate(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	m := newAnswerMatcher(cfg.Measurements[0].ExpectedAnswers)
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			msg := &mdns.Msg{}
+			for _, a := range test.answers {
+				rr, err := mdns.NewRR(a)
+				if err != nil {
+					te.Fatal(err)
+				}
+				msg.Answer = append(msg.Answer, rr)
+			}
+
+			assert.Equal(te, test.expected, m.matches(msg))
+		})
+	}
+}
@@ -16,11 +16,21 @@ const (
 func NewMeasurement(id, ipVersion string, cfg *config.Config) *exporter.Measurement {
 	opts := []exporter.MeasurementOpt{
 		exporter.WithHistograms(newRttHistogram(id, ipVersion, cfg.HistogramBuckets.DNS.Rtt)),
+		exporter.WithAggregators(&resolverSummary{id: id}),
 	}
 
 	if cfg.FilterInvalidResults {
 		opts = append(opts, exporter.WithValidator(&exporter.DefaultResultValidator{}))
 	}
 
-	return exporter.NewMeasurement(&dnsExporter{id}, opts...)
+	if cfg.MinFirmware > 0 {
+		opts = append(opts, exporter.WithMinFirmware(id, sub, cfg.MinFirmware))
+	}
+
+	e := newDNSExporter(id, cfg)
+	if mc := cfg.MeasurementByID(id); mc != nil && mc.ExpectedAnswers != nil {
+		e.answerMatcher = newAnswerMatcher(mc.ExpectedAnswers)
+	}
+
+	return exporter.NewMeasurement(e, opts...)
 }
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const dualStackStateTTL = 24 * time.Hour
+
+// the state is shared by all DNS measurements, so results of paired IPv4 and IPv6 measurements can be compared
+var dualStack = exporter.NewStateStore[dualStackRcodes](dualStackStateTTL)
+
+var dualStackDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(ns, sub, "dualstack_consistent"),
+	"Latest responses to queries for the name via IPv4 and IPv6 had the same response codes (1 = consistent)",
+	[]string{"measurement", "probe", "qname"},
+	constLabels,
+)
+
+// dualStackRcodes are the sorted and distinct response codes of the responses to queries for a name per address family
+type dualStackRcodes [2][]int
+
+// dualStackResponses collects the response codes of all responses of a result by query name
+type dualStackResponses map[string]*dualStackRcodes
+
+func afIndex(af int) int {
+	if af == 6 {
+		return 1
+	}
+
+	return 0
+}
+
+// add records the response code of a response to a query for qname via the address family
+func (r dualStackResponses) add(qname string, af int, rcode int) {
+	rcodes, found := r[qname]
+	if !found {
+		rcodes = &dualStackRcodes{}
+		r[qname] = rcodes
+	}
+
+	i := afIndex(af)
+	if !slices.Contains(rcodes[i], rcode) {
+		rcodes[i] = append(rcodes[i], rcode)
+		sort.Ints(rcodes[i])
+	}
+}
+
+// qnames returns the query names of the responses (sorted)
+func (r dualStackResponses) qnames() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// dualStackConsistent records the response codes of the latest responses to queries for qname by the probe
+// (address families without responses keep the codes of previous results) and returns if the response codes
+// for both address families are the same (ok is false as long as not both are known)
+func dualStackConsistent(probeID int, qname string, rcodes dualStackRcodes) (consistent bool, ok bool) {
+	s := dualStack.Update(strconv.Itoa(probeID)+"/"+qname, func(s dualStackRcodes, found bool) dualStackRcodes {
+		for i := range rcodes {
+			if len(rcodes[i]) > 0 {
+				s[i] = rcodes[i]
+			}
+		}
+		return s
+	})
+
+	if len(s[0]) == 0 || len(s[1]) == 0 {
+		return false, false
+	}
+
+	return slices.Equal(s[0], s[1]), true
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	mdns "github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDualStackConsistent(t *testing.T) {
+	_, ok := dualStackConsistent(1001, "example.com.", dualStackRcodes{{0}, nil})
+	assert.False(t, ok, "IPv6 response not known yet")
+
+	consistent, ok := dualStackConsistent(1001, "example.com.", dualStackRcodes{nil, {0}})
+	assert.True(t, ok)
+	assert.True(t, consistent)
+
+	consistent, ok = dualStackConsistent(1001, "example.com.", dualStackRcodes{nil, {2}})
+	assert.True(t, ok)
+	assert.False(t, consistent)
+
+	consistent, ok = dualStackConsistent(1001, "example.com.", dualStackRcodes{{0, 2}, {0, 2}})
+	assert.True(t, ok)
+	assert.True(t, consistent)
+
+	_, ok = dualStackConsistent(1001, "example.org.", dualStackRcodes{nil, {0}})
+	assert.False(t, ok, "other query name")
+}
+
+func TestDualStackResponses(t *testing.T) {
+	r := make(dualStackResponses)
+	r.add("example.org.", 4, 0)
+	r.add("example.com.", 6, 2)
+	r.add("example.com.", 4, 2)
+	r.add("example.com.", 4, 0)
+	r.add("example.com.", 4, 2)
+
+	assert.Equal(t, []string{"example.com.", "example.org."}, r.qnames())
+	assert.Equal(t, dualStackRcodes{{0, 2}, {2}}, *r["example.com."])
+}
+
+func TestDualStackMultipleResultsets(t *testing.T) {
+	msg := newMsg()
+	abuf := packMsg(t, msg)
+
+	servfail := newMsg()
+	servfail.Rcode = mdns.RcodeServerFailure
+	abufServfail := packMsg(t, servfail)
+
+	p := &probe.Probe{ID: 1002, Asn4: 64496, CountryCode: "DE"}
+	v4 := fmt.Sprintf(`{"type":"dns","prb_id":1002,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}},{"af":4,"dst_addr":"192.0.2.54","result":{"rt":20,"abuf":"%s"}}]}`, abuf, abuf)
+	v6 := fmt.Sprintf(`{"type":"dns","prb_id":1002,"msm_id":2,"resultset":[{"af":6,"dst_addr":"2001:db8::53","result":{"rt":12.5,"abuf":"%s"}},{"af":6,"dst_addr":"2001:db8::54","result":{"rt":20,"abuf":"%s"}}]}`, abuf, abufServfail)
+
+	cfg := &config.Config{DNS: config.DNSConfig{DualStack: true}}
+	m4 := NewMeasurement("1", "4", cfg)
+	m4.Add(parseResult(t, v4), p)
+	m6 := NewMeasurement("2", "6", cfg)
+	m6.Add(parseResult(t, v6), p)
+
+	for _, m := range []prometheus.Collector{m4, m6} {
+		reg := prometheus.NewPedanticRegistry()
+		reg.MustRegister(m)
+
+		for i := 0; i < 2; i++ {
+			_, err := reg.Gather()
+			assert.NoError(t, err)
+		}
+	}
+
+	expected := `
+# HELP atlas_dns_dualstack_consistent Latest responses to queries for the name via IPv4 and IPv6 had the same response codes (1 = consistent)
+# TYPE atlas_dns_dualstack_consistent gauge
+atlas_dns_dualstack_consistent{measurement="2",measurement_type="dns",probe="1002",qname="example.com."} 0
+`
+	assert.Equal(t, 1, testutil.CollectAndCount(m6, "atlas_dns_dualstack_consistent"))
+	err := testutil.CollectAndCompare(m6, strings.NewReader(expected), "atlas_dns_dualstack_consistent")
+	assert.NoError(t, err)
+}
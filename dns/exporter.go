@@ -3,205 +3,825 @@
 package dns
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	rdns "github.com/DNS-OARC/ripeatlas/measurement/dns"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/czerwonk/atlas_exporter/probe"
 	mdns "github.com/miekg/dns"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var (
-	labels      []string
-	successDesc *prometheus.Desc
-	rttDesc     *prometheus.Desc
-	answerDesc  *prometheus.Desc
+const (
+	answerModeSet   = "set"
+	answerModeCount = "count"
+)
+
+// reasons of the error metric
+const (
+	errorTimeout = "timeout"
+	errorRefused = "refused"
+	errorSocket  = "socket_error"
+	errorParse   = "parse_error"
 )
 
-func init() {
+var (
+	constLabels = prometheus.Labels{"measurement_type": sub}
+
 	labels = []string{"measurement", "probe", "dst_addr", "asn", "ip_version", "country_code", "lat", "long"}
+)
+
+type dnsExporter struct {
+	id                  string
+	answerMode          string
+	nsidLabel           bool
+	queryNameLabels     bool
+	resultsetLabel      bool
+	protoLabel          bool
+	dualStack           bool
+	srcAddrLabel        bool
+	coordinatePrecision int
+	failureThreshold    int
+	labelSet            *exporter.LabelSet
+	answerMatcher       *answerMatcher
+
+	successDesc       *prometheus.Desc
+	upDesc            *prometheus.Desc
+	errorDesc         *prometheus.Desc
+	rttDesc           *prometheus.Desc
+	answerDesc        *prometheus.Desc
+	answerSetInfoDesc *prometheus.Desc
+	answerTTLDesc     *prometheus.Desc
+	answerCountDesc   *prometheus.Desc
+	answerHashDesc    *prometheus.Desc
+	queryAFDesc       *prometheus.Desc
+	answerTypeDesc    *prometheus.Desc
+	rdataBytesDesc    *prometheus.Desc
+	minimalDesc       *prometheus.Desc
+	questionDesc      *prometheus.Desc
+	rcodeDesc         *prometheus.Desc
+	rcodeInfoDesc     *prometheus.Desc
+	authoritativeDesc *prometheus.Desc
+	ancountDesc       *prometheus.Desc
+	nscountDesc       *prometheus.Desc
+	arcountDesc       *prometheus.Desc
+	adDesc            *prometheus.Desc
+	rdDesc            *prometheus.Desc
+	raDesc            *prometheus.Desc
+	truncatedDesc     *prometheus.Desc
+	responseBytesDesc *prometheus.Desc
+	soaSerialDesc     *prometheus.Desc
+	ednsDesc          *prometheus.Desc
+	ednsUDPSizeDesc   *prometheus.Desc
+	ednsDODesc        *prometheus.Desc
+	rrsigExpDesc      *prometheus.Desc
+	retriesDesc       *prometheus.Desc
+	dnskeyDesc        *prometheus.Desc
+	answerMatchDesc   *prometheus.Desc
+}
+
+// newDNSExporter returns a new exporter (the labels of the metrics depend on the config)
+func newDNSExporter(id string, cfg *config.Config) *dnsExporter {
+	e := &dnsExporter{
+		id:                  id,
+		answerMode:          cfg.DNS.AnswerMode,
+		nsidLabel:           cfg.DNS.NSIDLabel,
+		queryNameLabels:     cfg.DNS.QueryLabels,
+		resultsetLabel:      cfg.DNS.ResultsetLabel,
+		protoLabel:          cfg.DNS.ProtoLabel,
+		dualStack:           cfg.DNS.DualStack,
+		srcAddrLabel:        cfg.SrcAddrLabel,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		failureThreshold:    cfg.FailureThreshold,
+	}
+
+	names := exporter.WithLabels(labels)
+	if e.resultsetLabel {
+		names = append(names, "resultset")
+	}
+	if e.protoLabel {
+		names = append(names, "proto")
+	}
+	if e.srcAddrLabel {
+		names = append(names, "src_addr")
+	}
+	e.labelSet = exporter.NewLabelSet(names, cfg)
 
-	successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", labels, nil)
-	rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Roundtrip time in ms", labels, nil)
-	answerDesc = prometheus.NewDesc(
+	l := e.labelSet.Names()
+	al := answerLabels(l)
+	ql := exporter.WithLabels(l, e.queryLabels()...)
+	e.successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", ql, constLabels)
+	if cfg.UpMetric {
+		e.upDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "up"), "Destination was reachable (same as success)", ql, constLabels)
+	}
+	e.errorDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "error"), "Query failed (reason = timeout, refused, socket_error or parse_error)", exporter.WithLabels(ql, "reason"), constLabels)
+	e.rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Roundtrip time in ms", ql, constLabels)
+	e.retriesDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "retries"), "Number of retries of the query", l, constLabels)
+	e.queryAFDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "query_af"), "Address family used to reach the resolver (4 or 6)", l, constLabels)
+	e.answerDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(ns, sub, "answer"),
-		"DNS answer IP for query",
-		[]string{
-			"measurement",
-			"probe",
-			"resolver",
-			"asn",
-			"ip_version",
-			"country_code",
-			"lat",
-			"long",
-			"qname",
-			"rr_type",
-			"answer_ip",
-		},
-		nil,
+		"DNS answer for query",
+		exporter.WithLabels(al, "qname", "rr_type", "answer_value"),
+		constLabels,
+	)
+	e.answerTTLDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "answer_ttl"),
+		"TTL of the DNS answer in seconds",
+		exporter.WithLabels(al, "qname", "rr_type", "answer_value"),
+		constLabels,
+	)
+	e.answerSetInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "answer_set_info"),
+		"Sorted and joined DNS answers for query",
+		exporter.WithLabels(al, "qname", "answers"),
+		constLabels,
+	)
+	e.answerCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "answer_count"),
+		"Number of DNS answers for query",
+		exporter.WithLabels(al, "qname"),
+		constLabels,
 	)
+	e.answerHashDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "answer_set_hash"),
+		"FNV-1a hash of the sorted DNS answers for query",
+		exporter.WithLabels(al, "qname"),
+		constLabels,
+	)
+	e.answerMatchDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "answer_match"), "All answers of the response match the expected answers configured for the measurement (1 = match)", l, constLabels)
+	e.rdataBytesDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "answer_rdata_bytes"), "Total size of RDATA of all records in the answer section in bytes", l, constLabels)
+	e.questionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "question"), "Question of the response (index = position in the question section)", exporter.WithLabels(l, "index", "qname", "qtype", "qclass"), constLabels)
+	e.rcodeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rcode"), "Response code of the response", l, constLabels)
+	e.rcodeInfoDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rcode_info"), "Name of the response code of the response", exporter.WithLabels(l, "rcode_name"), constLabels)
+	e.ancountDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "ancount"), "Number of records in the answer section", l, constLabels)
+	e.nscountDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "nscount"), "Number of records in the authority section", l, constLabels)
+	e.arcountDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "arcount"), "Number of records in the additional section", l, constLabels)
+	e.adDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "authenticated_data"), "Authenticated data (AD) flag of the response is set (DNSSEC validated by the resolver)", l, constLabels)
+	e.responseBytesDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "response_bytes"), "Size of the DNS response in bytes", l, constLabels)
+	e.truncatedDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "truncated"), "Truncated (TC) flag of the response is set", l, constLabels)
+	e.authoritativeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "authoritative_answer"), "Authoritative answer (AA) flag of the response is set", l, constLabels)
+	e.rdDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "recursion_desired"), "Recursion desired (RD) flag of the response is set", l, constLabels)
+	e.raDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "recursion_available"), "Recursion available (RA) flag of the response is set", l, constLabels)
+	e.minimalDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "minimal_response"), "Response contains neither authority nor additional records (OPT ignored)", l, constLabels)
+	e.soaSerialDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "soa_serial"), "Serial of the SOA record in the answer section", exporter.WithLabels(l, "zone"), constLabels)
+	e.ednsDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "edns"), "Response contains an EDNS OPT record", l, constLabels)
+	e.ednsUDPSizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "edns_udp_size"), "UDP payload size advertised in the EDNS OPT record of the response", l, constLabels)
+	e.ednsDODesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "edns_do"), "DNSSEC OK (DO) bit of the EDNS OPT record of the response is set", l, constLabels)
+	e.rrsigExpDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "rrsig_expiration_timestamp_seconds"),
+		"Expiration of the RRSIG records in the answer section as Unix timestamp",
+		exporter.WithLabels(l, "qname", "type_covered", "key_tag"),
+		constLabels,
+	)
+	e.dnskeyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "dnskey"),
+		"DNSKEY record in the answer section",
+		exporter.WithLabels(l, "qname", "key_tag", "algorithm", "flags"),
+		constLabels,
+	)
+	e.answerTypeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "answer_type_count"), "Number of records in the answer section by RR type", exporter.WithLabels(l, "rr_type"), constLabels)
+
+	return e
 }
 
-type dnsExporter struct {
-	id string
+// answerLabels returns the labels for answer metrics, which name the destination resolver
+func answerLabels(labels []string) []string {
+	res := exporter.WithLabels(labels)
+	for i, name := range res {
+		if name == "dst_addr" {
+			res[i] = "resolver"
+		}
+	}
+
+	return res
+}
+
+// query is a single DNS query of a result (results can contain multiple result sets)
+type query struct {
+	index     int
+	dstAddr   string
+	af        int
+	timestamp int
+	qbuf      string
+	proto     string
+	retry     int
+	srcAddr   string
+	result    *rdns.Result
+	err       *rdns.Error
 }
 
 // Export exports a prometheus metric
 func (m *dnsExporter) Export(res *measurement.Result, p *probe.Probe, ch chan<- prometheus.Metric) {
-	if rs := res.DnsResultsets(); len(rs) > 0 {
-		for _, s := range rs {
-			if s == nil {
-				continue
-			}
+	responses := make(dualStackResponses)
+	for _, q := range queriesForResult(res) {
+		msg := m.exportQuery(q, res, p, ch)
+		if msg == nil || !m.dualStack {
+			continue
+		}
 
-			labelValues := []string{
-				m.id,
-				strconv.Itoa(p.ID),
-				s.DstAddr(),
-				strconv.Itoa(p.ASNForIPVersion(s.Af())),
-				strconv.Itoa(s.Af()),
-				p.CountryCode,
-				p.Latitude(),
-				p.Longitude(),
-			}
+		if qs := question(msg, q.qbuf); qs != nil {
+			responses.add(qs.Name, queryAF(q.af, q.dstAddr, res.Af()), msg.Rcode)
+		}
+	}
 
-			if s.DnsError() != nil || s.Result() == nil {
-				ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
-				continue
-			}
+	if m.dualStack {
+		m.exportDualStack(responses, p, ch)
+	}
+}
 
-			r := s.Result()
-			rtt := r.Rt()
-
-			if msg, err := r.UnpackAbuf(); err == nil && msg != nil {
-				for _, ans := range msg.Answer {
-					switch rr := ans.(type) {
-					case *mdns.A:
-						ch <- prometheus.MustNewConstMetric(
-							answerDesc,
-							prometheus.GaugeValue,
-							1,
-							[]string{
-								m.id,
-								strconv.Itoa(p.ID),
-								s.DstAddr(),
-								strconv.Itoa(p.ASNForIPVersion(s.Af())),
-								strconv.Itoa(s.Af()),
-								p.CountryCode,
-								p.Latitude(),
-								p.Longitude(),
-								rr.Hdr.Name,
-								"A",
-								rr.A.String(),
-							}...,
-						)
-					case *mdns.AAAA:
-						ch <- prometheus.MustNewConstMetric(
-							answerDesc,
-							prometheus.GaugeValue,
-							1,
-							[]string{
-								m.id,
-								strconv.Itoa(p.ID),
-								s.DstAddr(),
-								strconv.Itoa(p.ASNForIPVersion(s.Af())),
-								strconv.Itoa(s.Af()),
-								p.CountryCode,
-								p.Latitude(),
-								p.Longitude(),
-								rr.Hdr.Name,
-								"AAAA",
-								rr.AAAA.String(),
-							}...,
-						)
-					}
-				}
-			}
+func queriesForResult(res *measurement.Result) []*query {
+	rs := res.DnsResultsets()
+	if len(rs) == 0 {
+		return []*query{
+			{
+				dstAddr:   res.DstAddr(),
+				af:        res.Af(),
+				timestamp: res.Timestamp(),
+				qbuf:      res.Qbuf(),
+				proto:     res.Proto(),
+				retry:     res.Retry(),
+				srcAddr:   res.SrcAddr(),
+				result:    res.DnsResult(),
+				err:       res.DnsError(),
+			},
+		}
+	}
+
+	queries := make([]*query, 0, len(rs))
+	for i, s := range rs {
+		if s == nil {
+			continue
+		}
+
+		srcAddr := res.SrcAddr()
+		if s.Result() != nil && s.Result().SrcAddr() != "" {
+			srcAddr = s.Result().SrcAddr()
+		}
+
+		queries = append(queries, &query{
+			index:     i,
+			dstAddr:   s.DstAddr(),
+			af:        s.Af(),
+			timestamp: s.Timestamp(),
+			qbuf:      s.Qbuf(),
+			proto:     s.Proto(),
+			retry:     s.Retry(),
+			srcAddr:   srcAddr,
+			result:    s.Result(),
+			err:       s.DnsError(),
+		})
+	}
+
+	return queries
+}
+
+// exportQuery exports the metrics of a single query and returns the response (nil if there was none or it could not be unpacked)
+func (m *dnsExporter) exportQuery(q *query, res *measurement.Result, p *probe.Probe, ch chan<- prometheus.Metric) *mdns.Msg {
+	labelValues := m.labelValues(p, q)
+	ch <- prometheus.MustNewConstMetric(m.queryAFDesc, prometheus.GaugeValue, float64(queryAF(q.af, q.dstAddr, res.Af())), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.retriesDesc, prometheus.GaugeValue, float64(q.retry), labelValues...)
+
+	// a response arrived when there is a result without error, the RTT can be 0 for local resolvers
+	ok := q.err == nil && q.result != nil
+
+	var msg *mdns.Msg
+	var unpackErr error
+	if ok {
+		msg, unpackErr = q.result.UnpackAbuf()
+	}
+
+	queryLabelValues := exporter.WithLabels(labelValues, m.queryLabelValues(msg, q.qbuf)...)
+	if reason := errorReason(q, msg, unpackErr); reason != "" {
+		ch <- prometheus.MustNewConstMetric(m.errorDesc, prometheus.GaugeValue, 1, exporter.WithLabels(queryLabelValues, reason)...)
+	}
+
+	key := m.id + "/" + strconv.Itoa(p.ID) + "/" + q.dstAddr
+	if m.resultsetLabel {
+		key += "/" + strconv.Itoa(q.index)
+	}
+	success := exporter.Success(key, q.timestamp, ok, m.failureThreshold)
+	ch <- prometheus.MustNewConstMetric(m.successDesc, prometheus.GaugeValue, success, queryLabelValues...)
+
+	if m.upDesc != nil {
+		ch <- prometheus.MustNewConstMetric(m.upDesc, prometheus.GaugeValue, success, queryLabelValues...)
+	}
+
+	if !ok {
+		return nil
+	}
 
-			if rtt > 0 {
-				ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 1, labelValues...)
-				ch <- prometheus.MustNewConstMetric(rttDesc, prometheus.GaugeValue, rtt, labelValues...)
-			} else {
-				ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
+	if msg != nil {
+		m.exportMessage(msg, labelValues, ch)
+	}
+
+	ch <- prometheus.MustNewConstMetric(m.rttDesc, prometheus.GaugeValue, q.result.Rt(), queryLabelValues...)
+	ch <- prometheus.MustNewConstMetric(m.responseBytesDesc, prometheus.GaugeValue, float64(responseSize(q.result)), labelValues...)
+
+	return msg
+}
+
+// exportDualStack exports the dual-stack consistency once per query name of the result (even if there were several queries for it)
+func (m *dnsExporter) exportDualStack(responses dualStackResponses, p *probe.Probe, ch chan<- prometheus.Metric) {
+	for _, qname := range responses.qnames() {
+		consistent, ok := dualStackConsistent(p.ID, qname, *responses[qname])
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(dualStackDesc, prometheus.GaugeValue, flagValue(consistent), m.id, strconv.Itoa(p.ID), qname)
+	}
+}
+
+// errorReason classifies the failure of a query (empty if the query succeeded).
+// Errors reported by the probe other than timeouts (e.g. getaddrinfo or socket failures) are classified as socket_error.
+func errorReason(q *query, msg *mdns.Msg, unpackErr error) string {
+	if q.err != nil {
+		if q.err.Timeout() > 0 {
+			return errorTimeout
+		}
+
+		return errorSocket
+	}
+
+	if q.result == nil {
+		return ""
+	}
+
+	if unpackErr != nil {
+		return errorParse
+	}
+
+	if msg != nil && msg.Rcode == mdns.RcodeRefused {
+		return errorRefused
+	}
+
+	return ""
+}
+
+// queryLabels returns the names of the optional labels of the success, error and rtt metrics describing the query
+func (m *dnsExporter) queryLabels() []string {
+	labels := []string{}
+	if m.nsidLabel {
+		labels = append(labels, "nsid")
+	}
+
+	if m.queryNameLabels {
+		labels = append(labels, "qname", "qtype")
+	}
+
+	return labels
+}
+
+// queryLabelValues returns the values of the optional labels of the success, error and rtt metrics (msg is nil if there was no response)
+func (m *dnsExporter) queryLabelValues(msg *mdns.Msg, qbuf string) []string {
+	values := []string{}
+	if m.nsidLabel {
+		values = append(values, nsid(msg))
+	}
+
+	if m.queryNameLabels {
+		qname, qtype := "", ""
+		if q := question(msg, qbuf); q != nil {
+			qname, qtype = q.Name, typeName(q.Qtype)
+		}
+		values = append(values, qname, qtype)
+	}
+
+	return values
+}
+
+// question returns the (first) question of the response or of the query sent by the probe if there was no response
+func question(msg *mdns.Msg, qbuf string) *mdns.Question {
+	if msg == nil || len(msg.Question) == 0 {
+		msg = unpackQbuf(qbuf)
+	}
+
+	if msg == nil || len(msg.Question) == 0 {
+		return nil
+	}
+
+	return &msg.Question[0]
+}
+
+func unpackQbuf(qbuf string) *mdns.Msg {
+	if len(qbuf) == 0 {
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(qbuf)
+	if err != nil {
+		return nil
+	}
+
+	msg := &mdns.Msg{}
+	if err := msg.Unpack(b); err != nil {
+		return nil
+	}
+
+	return msg
+}
+
+// nsid returns the name server identifier (RFC 5001) of the EDNS OPT record of the response
+func nsid(msg *mdns.Msg) string {
+	if msg == nil {
+		return ""
+	}
+
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return ""
+	}
+
+	for _, o := range opt.Option {
+		if n, ok := o.(*mdns.EDNS0_NSID); ok {
+			b, err := hex.DecodeString(n.Nsid)
+			if err != nil {
+				return n.Nsid
 			}
+
+			return string(b)
 		}
-		return
 	}
 
-	labelValues := []string{
+	return ""
+}
+
+// responseSize returns the size of the response reported by the probe or the length of the abuf if not reported
+func responseSize(res *rdns.Result) int {
+	if res.Size() > 0 {
+		return res.Size()
+	}
+
+	b, err := base64.StdEncoding.DecodeString(res.Abuf())
+	if err != nil {
+		return 0
+	}
+
+	return len(b)
+}
+
+// queryAF returns the address family of the transport used to reach the resolver.
+// If the result does not provide it, it is derived from the resolver address and falls back to the AF of the result.
+func queryAF(af int, dstAddr string, fallback int) int {
+	if af == 4 || af == 6 {
+		return af
+	}
+
+	if ip := net.ParseIP(dstAddr); ip != nil {
+		if ip.To4() != nil {
+			return 4
+		}
+
+		return 6
+	}
+
+	return fallback
+}
+
+func (m *dnsExporter) labelValues(p *probe.Probe, q *query) []string {
+	values := []string{
 		m.id,
 		strconv.Itoa(p.ID),
-		res.DstAddr(),
-		strconv.Itoa(p.ASNForIPVersion(res.Af())),
-		strconv.Itoa(res.Af()),
+		q.dstAddr,
+		strconv.Itoa(p.ASNForIPVersion(q.af)),
+		strconv.Itoa(q.af),
 		p.CountryCode,
-		p.Latitude(),
-		p.Longitude(),
-	}
-
-	var rtt float64
-	dnsRes := res.DnsResult()
-	if dnsRes != nil {
-		rtt = dnsRes.Rt()
-
-		if msg, err := dnsRes.UnpackAbuf(); err == nil && msg != nil {
-			for _, ans := range msg.Answer {
-				switch rr := ans.(type) {
-				case *mdns.A:
-					ch <- prometheus.MustNewConstMetric(
-						answerDesc,
-						prometheus.GaugeValue,
-						1,
-						[]string{
-							m.id,
-							strconv.Itoa(p.ID),
-							res.DstAddr(),
-							strconv.Itoa(p.ASNForIPVersion(res.Af())),
-							strconv.Itoa(res.Af()),
-							p.CountryCode,
-							p.Latitude(),
-							p.Longitude(),
-							rr.Hdr.Name,
-							"A",
-							rr.A.String(),
-						}...,
-					)
-				case *mdns.AAAA:
-					ch <- prometheus.MustNewConstMetric(
-						answerDesc,
-						prometheus.GaugeValue,
-						1,
-						[]string{
-							m.id,
-							strconv.Itoa(p.ID),
-							res.DstAddr(),
-							strconv.Itoa(p.ASNForIPVersion(res.Af())),
-							strconv.Itoa(res.Af()),
-							p.CountryCode,
-							p.Latitude(),
-							p.Longitude(),
-							rr.Hdr.Name,
-							"AAAA",
-							rr.AAAA.String(),
-						}...,
-					)
-				}
-			}
+		p.LatitudeWithPrecision(m.coordinatePrecision),
+		p.LongitudeWithPrecision(m.coordinatePrecision),
+	}
+
+	if m.resultsetLabel {
+		values = append(values, strconv.Itoa(q.index))
+	}
+
+	if m.protoLabel {
+		values = append(values, q.proto)
+	}
+
+	if m.srcAddrLabel {
+		values = append(values, q.srcAddr)
+	}
+
+	return m.labelSet.Values(values, p, q.af)
+}
+
+func (m *dnsExporter) exportMessage(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(m.rcodeDesc, prometheus.GaugeValue, float64(msg.Rcode), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.rcodeInfoDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, rcodeName(msg.Rcode))...)
+
+	m.exportQuestions(msg, labelValues, ch)
+	m.exportAnswers(msg, labelValues, ch)
+	m.exportAnswerTypes(msg, labelValues, ch)
+
+	if m.answerMatcher != nil {
+		ch <- prometheus.MustNewConstMetric(m.answerMatchDesc, prometheus.GaugeValue, flagValue(m.answerMatcher.matches(msg)), labelValues...)
+	}
+
+	m.exportSOASerials(msg, labelValues, ch)
+	m.exportRRSIGExpirations(msg, labelValues, ch)
+	m.exportDNSKEYs(msg, labelValues, ch)
+
+	var rdataBytes int
+	for _, ans := range msg.Answer {
+		rdataBytes += int(ans.Header().Rdlength)
+	}
+	ch <- prometheus.MustNewConstMetric(m.rdataBytesDesc, prometheus.GaugeValue, float64(rdataBytes), labelValues...)
+
+	ch <- prometheus.MustNewConstMetric(m.ancountDesc, prometheus.GaugeValue, float64(len(msg.Answer)), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.nscountDesc, prometheus.GaugeValue, float64(len(msg.Ns)), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.arcountDesc, prometheus.GaugeValue, float64(len(msg.Extra)), labelValues...)
+
+	ch <- prometheus.MustNewConstMetric(m.authoritativeDesc, prometheus.GaugeValue, flagValue(msg.Authoritative), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.adDesc, prometheus.GaugeValue, flagValue(msg.AuthenticatedData), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.rdDesc, prometheus.GaugeValue, flagValue(msg.RecursionDesired), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.raDesc, prometheus.GaugeValue, flagValue(msg.RecursionAvailable), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.truncatedDesc, prometheus.GaugeValue, flagValue(msg.Truncated), labelValues...)
+
+	m.exportEDNS(msg, labelValues, ch)
+
+	var minimal float64
+	if isMinimalResponse(msg) {
+		minimal = 1
+	}
+	ch <- prometheus.MustNewConstMetric(m.minimalDesc, prometheus.GaugeValue, minimal, labelValues...)
+}
+
+func (m *dnsExporter) exportEDNS(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	opt := msg.IsEdns0()
+	ch <- prometheus.MustNewConstMetric(m.ednsDesc, prometheus.GaugeValue, flagValue(opt != nil), labelValues...)
+
+	if opt == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(m.ednsUDPSizeDesc, prometheus.GaugeValue, float64(opt.UDPSize()), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.ednsDODesc, prometheus.GaugeValue, flagValue(opt.Do()), labelValues...)
+}
+
+func flagValue(set bool) float64 {
+	if set {
+		return 1
+	}
+
+	return 0
+}
+
+// isMinimalResponse returns true if authority and additional section are empty (EDNS OPT records are not counted)
+func isMinimalResponse(msg *mdns.Msg) bool {
+	if len(msg.Ns) > 0 {
+		return false
+	}
+
+	for _, rr := range msg.Extra {
+		if rr.Header().Rrtype != mdns.TypeOPT {
+			return false
 		}
 	}
 
-	if rtt > 0 {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 1, labelValues...)
-		ch <- prometheus.MustNewConstMetric(rttDesc, prometheus.GaugeValue, rtt, labelValues...)
-	} else {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
+	return true
+}
+
+func (m *dnsExporter) exportQuestions(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for i, q := range msg.Question {
+		ch <- prometheus.MustNewConstMetric(m.questionDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, strconv.Itoa(i), q.Name, typeName(q.Qtype), mdns.Class(q.Qclass).String())...)
 	}
 }
 
+func (m *dnsExporter) exportAnswers(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	switch m.answerMode {
+	case answerModeSet:
+		m.exportAnswerSets(msg, labelValues, ch)
+		return
+	case answerModeCount:
+		m.exportAnswerCounts(msg, labelValues, ch)
+		return
+	}
+
+	for _, ans := range msg.Answer {
+		rrType, value, ok := answerValue(ans)
+		if !ok {
+			continue
+		}
+
+		answerLabelValues := exporter.WithLabels(labelValues, ans.Header().Name, rrType, value)
+		ch <- prometheus.MustNewConstMetric(m.answerDesc, prometheus.GaugeValue, 1, answerLabelValues...)
+		ch <- prometheus.MustNewConstMetric(m.answerTTLDesc, prometheus.GaugeValue, float64(ans.Header().Ttl), answerLabelValues...)
+	}
+}
+
+func (m *dnsExporter) exportAnswerSets(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for qname, answers := range answerSets(msg) {
+		ch <- prometheus.MustNewConstMetric(m.answerSetInfoDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, qname, strings.Join(answers, ","))...)
+	}
+}
+
+func (m *dnsExporter) exportAnswerCounts(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for qname, answers := range answerSets(msg) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.Join(answers, ",")))
+
+		l := exporter.WithLabels(labelValues, qname)
+		ch <- prometheus.MustNewConstMetric(m.answerCountDesc, prometheus.GaugeValue, float64(len(answers)), l...)
+		ch <- prometheus.MustNewConstMetric(m.answerHashDesc, prometheus.GaugeValue, float64(h.Sum32()), l...)
+	}
+}
+
+// answerSets returns the sorted values of the answers by owner name
+func answerSets(msg *mdns.Msg) map[string][]string {
+	sets := make(map[string][]string)
+	for _, ans := range msg.Answer {
+		_, value, ok := answerValue(ans)
+		if !ok {
+			continue
+		}
+
+		qname := ans.Header().Name
+		sets[qname] = append(sets[qname], value)
+	}
+
+	for _, answers := range sets {
+		sort.Strings(answers)
+	}
+
+	return sets
+}
+
+func (m *dnsExporter) exportAnswerTypes(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	counts := make(map[string]int)
+	for _, ans := range msg.Answer {
+		counts[rrTypeName(ans)]++
+	}
+
+	for t, c := range counts {
+		ch <- prometheus.MustNewConstMetric(m.answerTypeDesc, prometheus.GaugeValue, float64(c), exporter.WithLabels(labelValues, t)...)
+	}
+}
+
+func (m *dnsExporter) exportSOASerials(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for _, ans := range msg.Answer {
+		if soa, ok := ans.(*mdns.SOA); ok {
+			ch <- prometheus.MustNewConstMetric(m.soaSerialDesc, prometheus.GaugeValue, float64(soa.Serial), exporter.WithLabels(labelValues, soa.Hdr.Name)...)
+		}
+	}
+}
+
+func (m *dnsExporter) exportRRSIGExpirations(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for _, ans := range msg.Answer {
+		sig, ok := ans.(*mdns.RRSIG)
+		if !ok {
+			continue
+		}
+
+		l := exporter.WithLabels(labelValues, sig.Hdr.Name, typeName(sig.TypeCovered), strconv.Itoa(int(sig.KeyTag)))
+		ch <- prometheus.MustNewConstMetric(m.rrsigExpDesc, prometheus.GaugeValue, float64(sig.Expiration), l...)
+	}
+}
+
+func (m *dnsExporter) exportDNSKEYs(msg *mdns.Msg, labelValues []string, ch chan<- prometheus.Metric) {
+	for _, ans := range msg.Answer {
+		key, ok := ans.(*mdns.DNSKEY)
+		if !ok {
+			continue
+		}
+
+		l := exporter.WithLabels(labelValues, key.Hdr.Name, strconv.Itoa(int(key.KeyTag())), algorithmName(key.Algorithm), strconv.Itoa(int(key.Flags)))
+		ch <- prometheus.MustNewConstMetric(m.dnskeyDesc, prometheus.GaugeValue, 1, l...)
+	}
+}
+
+func algorithmName(alg uint8) string {
+	if s, found := mdns.AlgorithmToString[alg]; found {
+		return s
+	}
+
+	return strconv.Itoa(int(alg))
+}
+
+func rrTypeName(rr mdns.RR) string {
+	return typeName(rr.Header().Rrtype)
+}
+
+func rcodeName(rcode int) string {
+	if s, found := mdns.RcodeToString[rcode]; found {
+		return s
+	}
+
+	return "UNKNOWN"
+}
+
+func typeName(t uint16) string {
+	if s, found := mdns.TypeToString[t]; found {
+		return s
+	}
+
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// answerValue returns the RR type and the value exported for an answer record
+func answerValue(rr mdns.RR) (rrType string, value string, ok bool) {
+	switch rr := rr.(type) {
+	case *mdns.A:
+		return "A", rr.A.String(), true
+	case *mdns.AAAA:
+		return "AAAA", rr.AAAA.String(), true
+	case *mdns.HTTPS:
+		return "HTTPS", svcbValue(&rr.SVCB), true
+	case *mdns.SVCB:
+		return "SVCB", svcbValue(rr), true
+	case *mdns.CNAME:
+		return "CNAME", rr.Target, true
+	case *mdns.NS:
+		return "NS", rr.Ns, true
+	case *mdns.MX:
+		return "MX", strconv.Itoa(int(rr.Preference)) + " " + rr.Mx, true
+	case *mdns.TXT:
+		return "TXT", strings.Join(rr.Txt, " "), true
+	case *mdns.SOA:
+		return "SOA", strings.TrimPrefix(rr.String(), rr.Hdr.String()), true
+	case *mdns.PTR:
+		return "PTR", rr.Ptr, true
+	case *mdns.SRV:
+		return "SRV", fmt.Sprintf("%d %d %d %s", rr.Priority, rr.Weight, rr.Port, rr.Target), true
+	case *mdns.CAA:
+		return "CAA", fmt.Sprintf("%d %s %s", rr.Flag, rr.Tag, rr.Value), true
+	case *mdns.NAPTR:
+		return "NAPTR", strings.TrimPrefix(rr.String(), rr.Hdr.String()), true
+	case *mdns.DNSKEY:
+		// the public key is omitted to keep the label value short
+		return "DNSKEY", fmt.Sprintf("%d %d %d %d", rr.Flags, rr.Protocol, rr.Algorithm, rr.KeyTag()), true
+	}
+
+	return "", "", false
+}
+
+// svcbValue formats priority, target and parameters (e.g. alpn, port, ipv4hint) of a SVCB/HTTPS record
+func svcbValue(rr *mdns.SVCB) string {
+	parts := []string{strconv.Itoa(int(rr.Priority)), rr.Target}
+	for _, kv := range rr.Value {
+		parts = append(parts, kv.Key().String()+"="+kv.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
 // Describe exports metric descriptions for Prometheus
 func (m *dnsExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- successDesc
-	ch <- rttDesc
-	ch <- answerDesc
+	ch <- m.successDesc
+	ch <- m.errorDesc
+	ch <- m.rttDesc
+	ch <- m.queryAFDesc
+	ch <- m.retriesDesc
+	ch <- m.answerTypeDesc
+	ch <- m.rdataBytesDesc
+	ch <- m.minimalDesc
+	ch <- m.authoritativeDesc
+	ch <- m.adDesc
+	ch <- m.rdDesc
+	ch <- m.raDesc
+	ch <- m.truncatedDesc
+	ch <- m.responseBytesDesc
+	ch <- m.ancountDesc
+	ch <- m.nscountDesc
+	ch <- m.arcountDesc
+	ch <- m.questionDesc
+	ch <- m.rcodeDesc
+	ch <- m.rcodeInfoDesc
+	ch <- m.soaSerialDesc
+	ch <- m.ednsDesc
+	ch <- m.ednsUDPSizeDesc
+	ch <- m.ednsDODesc
+	ch <- m.rrsigExpDesc
+	ch <- m.dnskeyDesc
+
+	switch m.answerMode {
+	case answerModeSet:
+		ch <- m.answerSetInfoDesc
+	case answerModeCount:
+		ch <- m.answerCountDesc
+		ch <- m.answerHashDesc
+	default:
+		ch <- m.answerDesc
+		ch <- m.answerTTLDesc
+	}
+
+	if m.upDesc != nil {
+		ch <- m.upDesc
+	}
+
+	if m.answerMatcher != nil {
+		ch <- m.answerMatchDesc
+	}
+
+	if m.dualStack {
+		ch <- dualStackDesc
+	}
 }
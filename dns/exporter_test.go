@@ -0,0 +1,981 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	mdns "github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccess(t *testing.T) {
+	abuf := packMsg(t, newMsg())
+
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:   "response with rtt",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, abuf),
+			expected: `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+`,
+		},
+		{
+			name:   "response with rtt of 0",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":0,"abuf":"%s"}}`, abuf),
+			expected: `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+`,
+		},
+		{
+			name:   "resultset response with rtt of 0",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","result":{"rt":0,"abuf":"%s"}}]}`, abuf),
+			expected: `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+`,
+		},
+		{
+			name:   "timeout",
+			result: `{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","error":{"timeout":5000}}`,
+			expected: `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 0
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, test.result), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(test.expected), "atlas_dns_success")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func testProbe() *probe.Probe {
+	return &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"}
+}
+
+func newMsg() *mdns.Msg {
+	msg := &mdns.Msg{}
+	msg.SetQuestion("example.com.", mdns.TypeA)
+	msg.Response = true
+
+	rr, _ := mdns.NewRR("example.com. 300 IN A 192.0.2.1")
+	msg.Answer = append(msg.Answer, rr)
+
+	return msg
+}
+
+func packMsg(t *testing.T, msg *mdns.Msg) string {
+	b, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func parseResult(t *testing.T, s string) *measurement.Result {
+	res := &measurement.Result{}
+	if err := json.Unmarshal([]byte(s), res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestAnswerValueSVCB(t *testing.T) {
+	rr, err := mdns.NewRR(`example.com. 300 IN HTTPS 1 . alpn="h3,h2" ipv4hint="192.0.2.1" ipv6hint="2001:db8::1"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rrType, value, ok := answerValue(rr)
+	assert.True(t, ok)
+	assert.Equal(t, "HTTPS", rrType)
+	assert.Equal(t, "1 . alpn=h3,h2 ipv4hint=192.0.2.1 ipv6hint=2001:db8::1", value)
+}
+
+func TestQuestions(t *testing.T) {
+	msg := newMsg()
+	msg.Question = append(msg.Question, mdns.Question{Name: "example.org.", Qtype: mdns.TypeAAAA, Qclass: mdns.ClassINET})
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_question Question of the response (index = position in the question section)
+# TYPE atlas_dns_question gauge
+atlas_dns_question{asn="64496",country_code="DE",dst_addr="192.0.2.53",index="0",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qclass="IN",qname="example.com.",qtype="A"} 1
+atlas_dns_question{asn="64496",country_code="DE",dst_addr="192.0.2.53",index="1",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qclass="IN",qname="example.org.",qtype="AAAA"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_question")
+	assert.NoError(t, err)
+}
+
+func TestConcurrentExporters(t *testing.T) {
+	res := parseResult(t, fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, newMsg())))
+
+	ids := []string{"1", "2"}
+	errs := make([]error, len(ids))
+	wg := sync.WaitGroup{}
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			m := NewMeasurement(id, "4", &config.Config{})
+			m.Add(res, testProbe())
+
+			expected := fmt.Sprintf(`
+# HELP atlas_dns_answer_type_count Number of records in the answer section by RR type
+# TYPE atlas_dns_answer_type_count gauge
+atlas_dns_answer_type_count{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="%s",measurement_type="dns",probe="1",rr_type="A"} 1
+`, id)
+			errs[i] = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_answer_type_count")
+		}(i, id)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestProbePrefixLabel(t *testing.T) {
+	res := parseResult(t, fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, newMsg())))
+	p := testProbe()
+	p.Prefix4 = "192.0.2.0/24"
+	p.Prefix6 = "2001:db8::/32"
+
+	expected := `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",prefix="192.0.2.0/24",probe="1"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{ProbePrefixLabel: true})
+	m.Add(res, p)
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_success")
+	assert.NoError(t, err)
+}
+
+func TestAnswerValue(t *testing.T) {
+	tests := []struct {
+		rr       string
+		rrType   string
+		expected string
+	}{
+		{rr: "example.com. 300 IN CNAME www.example.net.", rrType: "CNAME", expected: "www.example.net."},
+		{rr: "example.com. 300 IN NS ns1.example.com.", rrType: "NS", expected: "ns1.example.com."},
+		{rr: "example.com. 300 IN MX 10 mail.example.com.", rrType: "MX", expected: "10 mail.example.com."},
+		{rr: `example.com. 300 IN TXT "v=spf1" "-all"`, rrType: "TXT", expected: "v=spf1 -all"},
+		{rr: "example.com. 300 IN SOA ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300", rrType: "SOA", expected: "ns1.example.com. hostmaster.example.com. 1 7200 3600 1209600 300"},
+		{rr: "1.2.0.192.in-addr.arpa. 300 IN PTR www.example.com.", rrType: "PTR", expected: "www.example.com."},
+		{rr: "_sip._tcp.example.com. 300 IN SRV 10 60 5060 sip.example.com.", rrType: "SRV", expected: "10 60 5060 sip.example.com."},
+		{rr: `example.com. 300 IN CAA 0 issue "letsencrypt.org"`, rrType: "CAA", expected: "0 issue letsencrypt.org"},
+		{rr: `4.3.2.1.5.5.5.0.0.8.1.e164.arpa. 300 IN NAPTR 100 10 "u" "E2U+sip" "!^.*$!sip:info@example.com!" .`, rrType: "NAPTR", expected: `100 10 "u" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rrType, func(te *testing.T) {
+			rr, err := mdns.NewRR(test.rr)
+			if err != nil {
+				te.Fatal(err)
+			}
+
+			rrType, value, ok := answerValue(rr)
+			assert.True(te, ok)
+			assert.Equal(te, test.rrType, rrType)
+			assert.Equal(te, test.expected, value)
+		})
+	}
+}
+
+func TestRcode(t *testing.T) {
+	msg := newMsg()
+	msg.Rcode = mdns.RcodeServerFailure
+	msg.Answer = nil
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_rcode Response code of the response
+# TYPE atlas_dns_rcode gauge
+atlas_dns_rcode{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 2
+# HELP atlas_dns_rcode_info Name of the response code of the response
+# TYPE atlas_dns_rcode_info gauge
+atlas_dns_rcode_info{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",rcode_name="SERVFAIL"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rcode", "atlas_dns_rcode_info")
+	assert.NoError(t, err)
+}
+
+func TestNSIDLabel(t *testing.T) {
+	msg := newMsg()
+	msg.SetEdns0(1232, false)
+	opt := msg.IsEdns0()
+	opt.Option = append(opt.Option, &mdns.EDNS0_NSID{Code: mdns.EDNS0NSID, Nsid: hex.EncodeToString([]byte("fra1"))})
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_rtt Roundtrip time in ms
+# TYPE atlas_dns_rtt gauge
+atlas_dns_rtt{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",nsid="fra1",probe="1"} 12.5
+`
+
+	m := NewMeasurement("1", "4", &config.Config{DNS: config.DNSConfig{NSIDLabel: true}})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rtt")
+	assert.NoError(t, err)
+}
+
+func TestQueryLabels(t *testing.T) {
+	qbuf := packMsg(t, newMsg())
+
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:   "response",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, qbuf),
+			expected: `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",qtype="A"} 1
+`,
+		},
+		{
+			name:   "timeout",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","qbuf":"%s","error":{"timeout":5000}}`, qbuf),
+			expected: `
+# HELP atlas_dns_error Query failed (reason = timeout, refused, socket_error or parse_error)
+# TYPE atlas_dns_error gauge
+atlas_dns_error{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",qtype="A",reason="timeout"} 1
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",qtype="A"} 0
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			m := NewMeasurement("1", "4", &config.Config{DNS: config.DNSConfig{QueryLabels: true}})
+			m.Add(parseResult(te, test.result), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(test.expected), "atlas_dns_success", "atlas_dns_error")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	refused := newMsg()
+	refused.Rcode = mdns.RcodeRefused
+	refused.Answer = nil
+
+	tests := []struct {
+		name     string
+		result   string
+		expected string
+	}{
+		{
+			name:   "success",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, newMsg())),
+		},
+		{
+			name:   "timeout",
+			result: `{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","error":{"timeout":5000}}`,
+			expected: `
+# HELP atlas_dns_error Query failed (reason = timeout, refused, socket_error or parse_error)
+# TYPE atlas_dns_error gauge
+atlas_dns_error{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",reason="timeout"} 1
+`,
+		},
+		{
+			name:   "getaddrinfo",
+			result: `{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","error":{"getaddrinfo":"Name or service not known"}}`,
+			expected: `
+# HELP atlas_dns_error Query failed (reason = timeout, refused, socket_error or parse_error)
+# TYPE atlas_dns_error gauge
+atlas_dns_error{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",reason="socket_error"} 1
+`,
+		},
+		{
+			name:   "refused",
+			result: fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, refused)),
+			expected: `
+# HELP atlas_dns_error Query failed (reason = timeout, refused, socket_error or parse_error)
+# TYPE atlas_dns_error gauge
+atlas_dns_error{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",reason="refused"} 1
+`,
+		},
+		{
+			name:   "invalid abuf",
+			result: `{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"AAAA"}}`,
+			expected: `
+# HELP atlas_dns_error Query failed (reason = timeout, refused, socket_error or parse_error)
+# TYPE atlas_dns_error gauge
+atlas_dns_error{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",reason="parse_error"} 1
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, test.result), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(test.expected), "atlas_dns_error")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestSOASerial(t *testing.T) {
+	msg := &mdns.Msg{}
+	msg.SetQuestion("example.com.", mdns.TypeSOA)
+	msg.Response = true
+
+	rr, _ := mdns.NewRR("example.com. 300 IN SOA ns1.example.com. hostmaster.example.com. 2024010101 7200 3600 1209600 300")
+	msg.Answer = append(msg.Answer, rr)
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_soa_serial Serial of the SOA record in the answer section
+# TYPE atlas_dns_soa_serial gauge
+atlas_dns_soa_serial{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",zone="example.com."} 2.024010101e+09
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_soa_serial")
+	assert.NoError(t, err)
+}
+
+func TestEDNS(t *testing.T) {
+	msg := newMsg()
+	msg.SetEdns0(1232, true)
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_edns Response contains an EDNS OPT record
+# TYPE atlas_dns_edns gauge
+atlas_dns_edns{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+# HELP atlas_dns_edns_do DNSSEC OK (DO) bit of the EDNS OPT record of the response is set
+# TYPE atlas_dns_edns_do gauge
+atlas_dns_edns_do{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+# HELP atlas_dns_edns_udp_size UDP payload size advertised in the EDNS OPT record of the response
+# TYPE atlas_dns_edns_udp_size gauge
+atlas_dns_edns_udp_size{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1232
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_edns", "atlas_dns_edns_do", "atlas_dns_edns_udp_size")
+	assert.NoError(t, err)
+}
+
+func TestRRSIGExpiration(t *testing.T) {
+	msg := newMsg()
+	rr, err := mdns.NewRR("example.com. 300 IN RRSIG A 13 2 300 20240201000000 20240101000000 12345 example.com. dGVzdA==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Answer = append(msg.Answer, rr)
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_rrsig_expiration_timestamp_seconds Expiration of the RRSIG records in the answer section as Unix timestamp
+# TYPE atlas_dns_rrsig_expiration_timestamp_seconds gauge
+atlas_dns_rrsig_expiration_timestamp_seconds{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",key_tag="12345",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",type_covered="A"} 1.7067456e+09
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rrsig_expiration_timestamp_seconds")
+	assert.NoError(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","retry":2,"result":{"rt":12.5,"abuf":"%s"}}]}`, packMsg(t, newMsg()))
+	expected := `
+# HELP atlas_dns_retries Number of retries of the query
+# TYPE atlas_dns_retries gauge
+atlas_dns_retries{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 2
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_retries")
+	assert.NoError(t, err)
+}
+
+func TestResultsetLabel(t *testing.T) {
+	abuf := packMsg(t, newMsg())
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}},{"af":4,"dst_addr":"192.0.2.53","result":{"rt":20,"abuf":"%s"}}]}`, abuf, abuf)
+	expected := `
+# HELP atlas_dns_rtt Roundtrip time in ms
+# TYPE atlas_dns_rtt gauge
+atlas_dns_rtt{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",resultset="0"} 12.5
+atlas_dns_rtt{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",resultset="1"} 20
+`
+
+	m := NewMeasurement("1", "4", &config.Config{DNS: config.DNSConfig{ResultsetLabel: true}})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rtt")
+	assert.NoError(t, err)
+}
+
+func TestHeaderFlags(t *testing.T) {
+	msg := newMsg()
+	msg.Authoritative = true
+	msg.RecursionDesired = true
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := `
+# HELP atlas_dns_authoritative_answer Authoritative answer (AA) flag of the response is set
+# TYPE atlas_dns_authoritative_answer gauge
+atlas_dns_authoritative_answer{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+# HELP atlas_dns_recursion_available Recursion available (RA) flag of the response is set
+# TYPE atlas_dns_recursion_available gauge
+atlas_dns_recursion_available{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 0
+# HELP atlas_dns_recursion_desired Recursion desired (RD) flag of the response is set
+# TYPE atlas_dns_recursion_desired gauge
+atlas_dns_recursion_desired{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_authoritative_answer", "atlas_dns_recursion_available", "atlas_dns_recursion_desired")
+	assert.NoError(t, err)
+}
+
+func TestAnswerModeCount(t *testing.T) {
+	h := fnv.New32a()
+	h.Write([]byte("192.0.2.1,192.0.2.2"))
+
+	expected := fmt.Sprintf(`
+# HELP atlas_dns_answer_count Number of DNS answers for query
+# TYPE atlas_dns_answer_count gauge
+atlas_dns_answer_count{asn="64496",country_code="DE",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",resolver="192.0.2.53"} 2
+# HELP atlas_dns_answer_set_hash FNV-1a hash of the sorted DNS answers for query
+# TYPE atlas_dns_answer_set_hash gauge
+atlas_dns_answer_set_hash{asn="64496",country_code="DE",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",resolver="192.0.2.53"} %d
+`, h.Sum32())
+
+	tests := []struct {
+		name    string
+		answers []string
+	}{
+		{name: "sorted", answers: []string{"192.0.2.1", "192.0.2.2"}},
+		{name: "unsorted", answers: []string{"192.0.2.2", "192.0.2.1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			msg := newMsg()
+			msg.Answer = nil
+			for _, a := range test.answers {
+				rr, _ := mdns.NewRR("example.com. 300 IN A " + a)
+				msg.Answer = append(msg.Answer, rr)
+			}
+
+			result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(te, msg))
+			m := NewMeasurement("1", "4", &config.Config{DNS: config.DNSConfig{AnswerMode: "count"}})
+			m.Add(parseResult(te, result), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_answer_count", "atlas_dns_answer_set_hash", "atlas_dns_answer")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestDNSKEY(t *testing.T) {
+	msg := newMsg()
+	msg.Answer = nil
+	rr, err := mdns.NewRR("example.com. 3600 IN DNSKEY 257 3 13 mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Answer = append(msg.Answer, rr)
+	keyTag := rr.(*mdns.DNSKEY).KeyTag()
+
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":12.5,"abuf":"%s"}}`, packMsg(t, msg))
+	expected := fmt.Sprintf(`
+# HELP atlas_dns_dnskey DNSKEY record in the answer section
+# TYPE atlas_dns_dnskey gauge
+atlas_dns_dnskey{algorithm="ECDSAP256SHA256",asn="64496",country_code="DE",dst_addr="192.0.2.53",flags="257",ip_version="4",key_tag="%d",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com."} 1
+`, keyTag)
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_dnskey")
+	assert.NoError(t, err)
+
+	_, value, ok := answerValue(rr)
+	assert.True(t, ok)
+	assert.Equal(t, fmt.Sprintf("257 3 13 %d", keyTag), value)
+}
+
+func TestRttHistogram(t *testing.T) {
+	abuf := packMsg(t, newMsg())
+	results := []string{
+		fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":0,"abuf":"%s"}}`, abuf),
+		fmt.Sprintf(`{"type":"dns","prb_id":2,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","result":{"rt":15,"abuf":"%s"}},{"af":4,"dst_addr":"192.0.2.54","error":{"timeout":5000}}]}`, abuf),
+	}
+	expected := `
+# HELP atlas_dns_rtt_hist Histogram of round trip times over all DNS requests
+# TYPE atlas_dns_rtt_hist histogram
+atlas_dns_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="dns",le="10"} 1
+atlas_dns_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="dns",le="20"} 2
+atlas_dns_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="dns",le="+Inf"} 2
+atlas_dns_rtt_hist_sum{ip_version="4",measurement="1",measurement_type="dns"} 15
+atlas_dns_rtt_hist_count{ip_version="4",measurement="1",measurement_type="dns"} 2
+`
+
+	m := NewMeasurement("1", "4", &config.Config{HistogramBuckets: config.HistogramBuckets{DNS: config.RttHistogramBucket{Rtt: []float64{10, 20}}}})
+	for i, r := range results {
+		p := testProbe()
+		p.ID = i + 1
+		m.Add(parseResult(t, r), p)
+	}
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rtt_hist")
+	assert.NoError(t, err)
+}
+
+func TestProtoLabel(t *testing.T) {
+	result := fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","proto":"TCP","result":{"rt":12.5,"abuf":"%s"}}]}`, packMsg(t, newMsg()))
+	expected := `
+# HELP atlas_dns_success Destination was reachable
+# TYPE atlas_dns_success gauge
+atlas_dns_success{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",proto="TCP"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{DNS: config.DNSConfig{ProtoLabel: true}})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_success")
+	assert.NoError(t, err)
+}
+
+func TestQueryAF(t *testing.T) {
+	abuf := packMsg(t, newMsg())
+	result := fmt.Sprintf(`{
+		"fw": 5080,
+		"lts": 24,
+		"resultset": [
+			{"time": 1700000000, "lts": 24, "subid": 1, "submax": 3, "dst_addr": "192.0.2.53", "dst_port": "53", "af": 4, "src_addr": "192.168.1.10", "proto": "UDP", "result": {"rt": 12.5, "size": 45, "abuf": "%s"}},
+			{"time": 1700000001, "lts": 24, "subid": 2, "submax": 3, "dst_addr": "2001:db8::53", "dst_port": "53", "src_addr": "2001:db8:1::10", "proto": "UDP", "result": {"rt": 14.1, "size": 45, "abuf": "%s"}},
+			{"time": 1700000002, "lts": 24, "subid": 3, "submax": 3, "dst_addr": "resolver.example", "dst_port": "53", "proto": "UDP", "result": {"rt": 15.3, "size": 45, "abuf": "%s"}}
+		],
+		"msm_id": 1,
+		"prb_id": 1,
+		"timestamp": 1700000000,
+		"msm_name": "Tdig",
+		"from": "198.51.100.10",
+		"type": "dns",
+		"af": 6,
+		"group_id": 1
+	}`, abuf, abuf, abuf)
+
+	expected := `
+# HELP atlas_dns_query_af Address family used to reach the resolver (4 or 6)
+# TYPE atlas_dns_query_af gauge
+atlas_dns_query_af{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 4
+atlas_dns_query_af{asn="64496",country_code="DE",dst_addr="2001:db8::53",ip_version="0",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 6
+atlas_dns_query_af{asn="64496",country_code="DE",dst_addr="resolver.example",ip_version="0",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 6
+`
+
+	m := NewMeasurement("1", "6", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_query_af")
+	assert.NoError(t, err)
+}
+
+// atlasResult returns a DNS result as published by RIPE Atlas querying a single resolver with the response abuf
+func atlasResult(abuf string) string {
+	return fmt.Sprintf(`{
+		"fw": 5080,
+		"lts": 24,
+		"dst_addr": "192.0.2.53",
+		"dst_port": "53",
+		"af": 4,
+		"src_addr": "192.168.1.10",
+		"proto": "UDP",
+		"result": {"rt": 12.5, "size": 45, "abuf": "%s", "ID": 4711, "ANCOUNT": 1, "QDCOUNT": 1, "NSCOUNT": 0, "ARCOUNT": 0},
+		"msm_id": 1,
+		"prb_id": 1,
+		"timestamp": 1700000000,
+		"msm_name": "Tdig",
+		"from": "198.51.100.10",
+		"type": "dns",
+		"group_id": 1
+	}`, abuf)
+}
+
+func TestAnswerTypeCount(t *testing.T) {
+	msg := newMsg()
+	msg.SetQuestion("www.example.com.", mdns.TypeA)
+	msg.Answer = nil
+	for _, s := range []string{
+		"www.example.com. 300 IN CNAME example.com.",
+		"example.com. 300 IN A 192.0.2.1",
+		"example.com. 300 IN A 192.0.2.2",
+		"example.com. 300 IN RRSIG A 13 2 300 20301231000000 20201231000000 12345 example.com. dGVzdA==",
+	} {
+		rr, err := mdns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Answer = append(msg.Answer, rr)
+	}
+
+	expected := `
+# HELP atlas_dns_answer_type_count Number of records in the answer section by RR type
+# TYPE atlas_dns_answer_type_count gauge
+atlas_dns_answer_type_count{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",rr_type="A"} 2
+atlas_dns_answer_type_count{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",rr_type="CNAME"} 1
+atlas_dns_answer_type_count{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",rr_type="RRSIG"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, atlasResult(packMsg(t, msg))), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_answer_type_count")
+	assert.NoError(t, err)
+}
+
+func TestAnswerRdataBytes(t *testing.T) {
+	msg := newMsg()
+	rr, err := mdns.NewRR("example.com. 300 IN AAAA 2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Answer = append(msg.Answer, rr)
+
+	expected := `
+# HELP atlas_dns_answer_rdata_bytes Total size of RDATA of all records in the answer section in bytes
+# TYPE atlas_dns_answer_rdata_bytes gauge
+atlas_dns_answer_rdata_bytes{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 20
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, atlasResult(packMsg(t, msg))), testProbe())
+
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_answer_rdata_bytes")
+	assert.NoError(t, err)
+}
+
+func TestMinimalResponse(t *testing.T) {
+	minimal := newMsg()
+	minimal.SetEdns0(1232, true)
+
+	ns, err := mdns.NewRR("example.com. 3600 IN NS ns1.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withAuthority := newMsg()
+	withAuthority.SetEdns0(1232, true)
+	withAuthority.Ns = append(withAuthority.Ns, ns)
+
+	tests := []struct {
+		name     string
+		msg      *mdns.Msg
+		expected string
+	}{
+		{name: "only OPT in additional section", msg: minimal, expected: "1"},
+		{name: "NS in authority section", msg: withAuthority, expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			expected := `
+# HELP atlas_dns_minimal_response Response contains neither authority nor additional records (OPT ignored)
+# TYPE atlas_dns_minimal_response gauge
+atlas_dns_minimal_response{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} ` + test.expected + `
+`
+
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, atlasResult(packMsg(te, test.msg))), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_minimal_response")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestRcodeInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		rcode    int
+		expected string
+	}{
+		{name: "NOERROR", rcode: mdns.RcodeSuccess, expected: "NOERROR"},
+		{name: "NXDOMAIN", rcode: mdns.RcodeNameError, expected: "NXDOMAIN"},
+		{name: "unassigned rcode", rcode: 12, expected: "UNKNOWN"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			msg := newMsg()
+			msg.Rcode = test.rcode
+
+			expected := `
+# HELP atlas_dns_rcode_info Name of the response code of the response
+# TYPE atlas_dns_rcode_info gauge
+atlas_dns_rcode_info{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",rcode_name="` + test.expected + `"} 1
+`
+
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, atlasResult(packMsg(te, msg))), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_rcode_info")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestAuthoritativeAnswer(t *testing.T) {
+	authoritative := newMsg()
+	authoritative.Authoritative = true
+	recursive := newMsg()
+	recursive.RecursionAvailable = true
+
+	result := fmt.Sprintf(`{
+		"fw": 5080,
+		"lts": 24,
+		"af": 4,
+		"src_addr": "192.168.1.10",
+		"msm_id": 1,
+		"prb_id": 1,
+		"timestamp": 1700000000,
+		"msm_name": "Tdig",
+		"from": "198.51.100.10",
+		"type": "dns",
+		"group_id": 1,
+		"resultset": [
+			{"time": 1700000000, "lts": 24, "subid": 1, "submax": 2, "dst_addr": "192.0.2.53", "dst_port": "53", "af": 4, "src_addr": "192.168.1.10", "proto": "UDP", "result": {"rt": 12.5, "size": 45, "abuf": "%s"}},
+			{"time": 1700000001, "lts": 25, "subid": 2, "submax": 2, "dst_addr": "192.0.2.54", "dst_port": "53", "af": 4, "src_addr": "192.168.1.10", "proto": "UDP", "result": {"rt": 20.1, "size": 45, "abuf": "%s"}}
+		]
+	}`, packMsg(t, authoritative), packMsg(t, recursive))
+
+	expected := `
+# HELP atlas_dns_authoritative_answer Authoritative answer (AA) flag of the response is set
+# TYPE atlas_dns_authoritative_answer gauge
+atlas_dns_authoritative_answer{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+atlas_dns_authoritative_answer{asn="64496",country_code="DE",dst_addr="192.0.2.54",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 0
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, result), testProbe())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_authoritative_answer")
+	assert.NoError(t, err)
+}
+
+func TestAnswerTTL(t *testing.T) {
+	msg := newMsg()
+	rr, err := mdns.NewRR("example.com. 60 IN AAAA 2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Answer = append(msg.Answer, rr)
+
+	expected := `
+# HELP atlas_dns_answer_ttl TTL of the DNS answer in seconds
+# TYPE atlas_dns_answer_ttl gauge
+atlas_dns_answer_ttl{answer_value="192.0.2.1",asn="64496",country_code="DE",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",resolver="192.0.2.53",rr_type="A"} 300
+atlas_dns_answer_ttl{answer_value="2001:db8::1",asn="64496",country_code="DE",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1",qname="example.com.",resolver="192.0.2.53",rr_type="AAAA"} 60
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, atlasResult(packMsg(t, msg))), testProbe())
+
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_answer_ttl")
+	assert.NoError(t, err)
+}
+
+func TestSectionCounts(t *testing.T) {
+	msg := newMsg()
+	for _, s := range []string{
+		"example.com. 3600 IN NS ns1.example.com.",
+		"example.com. 3600 IN NS ns2.example.com.",
+	} {
+		rr, err := mdns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Ns = append(msg.Ns, rr)
+	}
+	glue, err := mdns.NewRR("ns1.example.com. 3600 IN A 192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Extra = append(msg.Extra, glue)
+	msg.SetEdns0(1232, false)
+
+	expected := `
+# HELP atlas_dns_ancount Number of records in the answer section
+# TYPE atlas_dns_ancount gauge
+atlas_dns_ancount{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 1
+# HELP atlas_dns_arcount Number of records in the additional section
+# TYPE atlas_dns_arcount gauge
+atlas_dns_arcount{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 2
+# HELP atlas_dns_nscount Number of records in the authority section
+# TYPE atlas_dns_nscount gauge
+atlas_dns_nscount{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} 2
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(parseResult(t, atlasResult(packMsg(t, msg))), testProbe())
+
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_ancount", "atlas_dns_nscount", "atlas_dns_arcount")
+	assert.NoError(t, err)
+}
+
+func TestAuthenticatedData(t *testing.T) {
+	validated := newMsg()
+	validated.AuthenticatedData = true
+
+	tests := []struct {
+		name     string
+		msg      *mdns.Msg
+		expected string
+	}{
+		{name: "validated", msg: validated, expected: "1"},
+		{name: "not validated", msg: newMsg(), expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			expected := `
+# HELP atlas_dns_authenticated_data Authenticated data (AD) flag of the response is set (DNSSEC validated by the resolver)
+# TYPE atlas_dns_authenticated_data gauge
+atlas_dns_authenticated_data{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} ` + test.expected + `
+`
+
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, atlasResult(packMsg(te, test.msg))), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_authenticated_data")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	truncated := newMsg()
+	truncated.Truncated = true
+	truncated.Answer = nil
+
+	tests := []struct {
+		name     string
+		msg      *mdns.Msg
+		expected string
+	}{
+		{name: "truncated", msg: truncated, expected: "1"},
+		{name: "complete", msg: newMsg(), expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			expected := `
+# HELP atlas_dns_truncated Truncated (TC) flag of the response is set
+# TYPE atlas_dns_truncated gauge
+atlas_dns_truncated{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} ` + test.expected + `
+`
+
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, atlasResult(packMsg(te, test.msg))), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_truncated")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestResponseBytes(t *testing.T) {
+	msg := newMsg()
+	rr, err := mdns.NewRR("example.com. 300 IN AAAA 2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Answer = append(msg.Answer, rr)
+
+	abuf := packMsg(t, msg)
+	b, err := base64.StdEncoding.DecodeString(abuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		result   string
+		expected int
+	}{
+		{name: "size reported by the probe", result: atlasResult(abuf), expected: 45},
+		{name: "size not reported", result: strings.Replace(atlasResult(abuf), `"size": 45, `, "", 1), expected: len(b)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			expected := fmt.Sprintf(`
+# HELP atlas_dns_response_bytes Size of the DNS response in bytes
+# TYPE atlas_dns_response_bytes gauge
+atlas_dns_response_bytes{asn="64496",country_code="DE",dst_addr="192.0.2.53",ip_version="4",lat="",long="",measurement="1",measurement_type="dns",probe="1"} %d
+`, test.expected)
+
+			m := NewMeasurement("1", "4", &config.Config{})
+			m.Add(parseResult(te, test.result), testProbe())
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_response_bytes")
+			assert.NoError(te, err)
+		})
+	}
+}
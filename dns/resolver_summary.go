@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"sort"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	resolverLabels = []string{"measurement", "dst_addr"}
+
+	resolverSuccessRatioDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "resolver_success_ratio"), "Ratio of queries to the resolver a response was received for over all probes", resolverLabels, constLabels)
+	resolverRttMedianDesc    = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "resolver_rtt_median"), "Median roundtrip time in ms of the responses of the resolver over all probes", resolverLabels, constLabels)
+	resolverProbesDesc       = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "resolver_probes"), "Number of probes that queried the resolver", resolverLabels, constLabels)
+)
+
+type resolverSummary struct {
+	id string
+}
+
+type resolverStats struct {
+	queries int
+	rtts    []float64
+	probes  map[int]bool
+}
+
+// Aggregate exports success ratio, median RTT and number of probes per resolver
+func (a *resolverSummary) Aggregate(results []*measurement.Result, ch chan<- prometheus.Metric) {
+	stats := make(map[string]*resolverStats)
+	for _, res := range results {
+		for _, q := range queriesForResult(res) {
+			s, found := stats[q.dstAddr]
+			if !found {
+				s = &resolverStats{probes: make(map[int]bool)}
+				stats[q.dstAddr] = s
+			}
+
+			s.queries++
+			s.probes[res.PrbId()] = true
+			if q.err == nil && q.result != nil {
+				s.rtts = append(s.rtts, q.result.Rt())
+			}
+		}
+	}
+
+	for dstAddr, s := range stats {
+		ch <- prometheus.MustNewConstMetric(resolverSuccessRatioDesc, prometheus.GaugeValue, float64(len(s.rtts))/float64(s.queries), a.id, dstAddr)
+		ch <- prometheus.MustNewConstMetric(resolverProbesDesc, prometheus.GaugeValue, float64(len(s.probes)), a.id, dstAddr)
+
+		if len(s.rtts) > 0 {
+			ch <- prometheus.MustNewConstMetric(resolverRttMedianDesc, prometheus.GaugeValue, median(s.rtts), a.id, dstAddr)
+		}
+	}
+}
+
+// Describe exports metric descriptions for Prometheus
+func (a *resolverSummary) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resolverSuccessRatioDesc
+	ch <- resolverRttMedianDesc
+	ch <- resolverProbesDesc
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
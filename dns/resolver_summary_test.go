@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolverSummary(t *testing.T) {
+	abuf := packMsg(t, newMsg())
+	results := []string{
+		fmt.Sprintf(`{"type":"dns","prb_id":1,"msm_id":1,"resultset":[{"af":4,"dst_addr":"192.0.2.53","result":{"rt":10,"abuf":"%s"}},{"af":4,"dst_addr":"198.51.100.53","result":{"rt":5,"abuf":"%s"}}]}`, abuf, abuf),
+		fmt.Sprintf(`{"type":"dns","prb_id":2,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","result":{"rt":20,"abuf":"%s"}}`, abuf),
+		`{"type":"dns","prb_id":3,"msm_id":1,"af":4,"dst_addr":"192.0.2.53","error":{"timeout":5000}}`,
+	}
+	expected := `
+# HELP atlas_dns_resolver_probes Number of probes that queried the resolver
+# TYPE atlas_dns_resolver_probes gauge
+atlas_dns_resolver_probes{dst_addr="192.0.2.53",measurement="1",measurement_type="dns"} 3
+atlas_dns_resolver_probes{dst_addr="198.51.100.53",measurement="1",measurement_type="dns"} 1
+# HELP atlas_dns_resolver_rtt_median Median roundtrip time in ms of the responses of the resolver over all probes
+# TYPE atlas_dns_resolver_rtt_median gauge
+atlas_dns_resolver_rtt_median{dst_addr="192.0.2.53",measurement="1",measurement_type="dns"} 15
+atlas_dns_resolver_rtt_median{dst_addr="198.51.100.53",measurement="1",measurement_type="dns"} 5
+# HELP atlas_dns_resolver_success_ratio Ratio of queries to the resolver a response was received for over all probes
+# TYPE atlas_dns_resolver_success_ratio gauge
+atlas_dns_resolver_success_ratio{dst_addr="192.0.2.53",measurement="1",measurement_type="dns"} 0.6666666666666666
+atlas_dns_resolver_success_ratio{dst_addr="198.51.100.53",measurement="1",measurement_type="dns"} 1
+`
+
+	m := NewMeasurement("1", "4", &config.Config{})
+	for i, r := range results {
+		p := testProbe()
+		p.ID = i + 1
+		m.Add(parseResult(t, r), p)
+	}
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_dns_resolver_probes", "atlas_dns_resolver_rtt_median", "atlas_dns_resolver_success_ratio")
+	assert.NoError(t, err)
+}
+
+func TestMedian(t *testing.T) {
+	assert.Equal(t, float64(2), median([]float64{3, 1, 2}))
+	assert.Equal(t, float64(2.5), median([]float64{4, 1, 3, 2}))
+}
@@ -25,31 +25,19 @@ func newRttHistogram(id, ipVersion string, buckets []float64) exporter.Histogram
 			Buckets:   buckets,
 			Help:      "Histogram of round trip times over all DNS requests",
 			ConstLabels: prometheus.Labels{
-				"measurement": id,
-				"ip_version":  ipVersion,
+				"measurement":      id,
+				"ip_version":       ipVersion,
+				"measurement_type": sub,
 			},
 		}),
 	}
 }
 
+// ProcessResult observes the RTT of all responses of the result (the RTT can be 0 for local resolvers)
 func (h *rttHistogram) ProcessResult(r *measurement.Result) {
-	if rs := r.DnsResultsets(); len(rs) > 0 {
-		for _, s := range rs {
-			if s == nil || s.Result() == nil {
-				continue
-			}
-
-			rt := s.Result().Rt()
-			if rt > 0 {
-				h.rtt.Observe(rt)
-			}
-		}
-		return
-	}
-
-	if dr := r.DnsResult(); dr != nil {
-		if rt := dr.Rt(); rt > 0 {
-			h.rtt.Observe(rt)
+	for _, q := range queriesForResult(r) {
+		if q.err == nil && q.result != nil {
+			h.rtt.Observe(q.result.Rt())
 		}
 	}
 }
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Aggregator exports metrics aggregated over the latest results of all probes of a measurement
+type Aggregator interface {
+	// Aggregate exports metrics for the latest results of the measurement
+	Aggregate(results []*measurement.Result, ch chan<- prometheus.Metric)
+
+	// Describe exports metric descriptions for Prometheus
+	Describe(ch chan<- *prometheus.Desc)
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+)
+
+// LabelSet defines the labels common to all metrics of a measurement: the labels of the measurement type,
+// optional labels with information about the probe and labels dropped by config
+type LabelSet struct {
+	names  []string
+	keep   []bool
+	prefix bool
+}
+
+// NewLabelSet returns the label set for the labels of a measurement type with the options of the config applied
+func NewLabelSet(names []string, cfg *config.Config) *LabelSet {
+	l := &LabelSet{
+		prefix: cfg.ProbePrefixLabel,
+	}
+
+	l.names = WithLabels(names)
+	if l.prefix {
+		l.names = append(l.names, "prefix")
+	}
+
+	drop := make(map[string]bool)
+	for _, name := range cfg.DropLabels {
+		drop[name] = true
+	}
+
+	l.keep = make([]bool, len(l.names))
+	for i, name := range l.names {
+		l.keep[i] = !drop[name]
+	}
+
+	return l
+}
+
+// Names returns the names of the labels
+func (l *LabelSet) Names() []string {
+	return l.filter(l.names)
+}
+
+// Values returns the label values for values of the labels of the measurement type (for the address family of the result)
+func (l *LabelSet) Values(values []string, p *probe.Probe, af int) []string {
+	if l.prefix {
+		values = WithLabels(values, p.PrefixForIPVersion(af))
+	}
+
+	return l.filter(values)
+}
+
+func (l *LabelSet) filter(values []string) []string {
+	res := make([]string, 0, len(values))
+	for i, v := range values {
+		if l.keep[i] {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// WithLabels returns a copy of labels with values appended. The result never shares its backing array
+// with labels, so it is safe to derive several label sets from the same (global) slice.
+func WithLabels(labels []string, values ...string) []string {
+	res := make([]string, 0, len(labels)+len(values))
+	res = append(res, labels...)
+	return append(res, values...)
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLabelsDoesNotAlias(t *testing.T) {
+	// spare capacity would let append write into the shared backing array
+	base := make([]string, 2, 10)
+	base[0], base[1] = "measurement", "probe"
+
+	results := make([][]string, 2)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = WithLabels(base, "label"+strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"measurement", "probe", "label0"}, results[0])
+	assert.Equal(t, []string{"measurement", "probe", "label1"}, results[1])
+	assert.Equal(t, []string{"measurement", "probe"}, base)
+
+	results[0][0] = "changed"
+	assert.Equal(t, "measurement", base[0])
+	assert.Equal(t, "measurement", results[1][0])
+}
+
+func TestLabelSet(t *testing.T) {
+	cfg := &config.Config{
+		ProbePrefixLabel: true,
+		DropLabels:       []string{"lat", "long"},
+	}
+	l := NewLabelSet([]string{"measurement", "probe", "lat", "long"}, cfg)
+
+	p := &probe.Probe{ID: 1, Prefix4: "192.0.2.0/24", Prefix6: "2001:db8::/32"}
+	assert.Equal(t, []string{"measurement", "probe", "prefix"}, l.Names())
+	assert.Equal(t, []string{"1", "1", "2001:db8::/32"}, l.Values([]string{"1", "1", "52.1", "13.4"}, p, 6))
+}
@@ -8,8 +8,23 @@ import (
 	"github.com/DNS-OARC/ripeatlas/measurement"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
 )
 
+// ExportPanics counts panics recovered while exporting results
+var ExportPanics = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "atlas",
+	Name:      "export_panics",
+	Help:      "Number of results which could not be exported due to a panic",
+})
+
+// ResultsSkippedFirmware counts results skipped since the firmware of the probe is older than the configured minimum
+var ResultsSkippedFirmware = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "atlas",
+	Name:      "results_skipped_firmware",
+	Help:      "Number of results skipped since the firmware of the probe is older than the configured minimum",
+}, []string{"measurement", "measurement_type"})
+
 // MeasurementOpt are options to apply to the `Measurement`
 type MeasurementOpt func(r *Measurement)
 
@@ -20,6 +35,13 @@ func WithHistograms(h ...Histogram) MeasurementOpt {
 	}
 }
 
+// WithAggregators adds aggregators exporting metrics over the results of all probes to the measurement
+func WithAggregators(a ...Aggregator) MeasurementOpt {
+	return func(r *Measurement) {
+		r.aggregators = append(r.aggregators, a...)
+	}
+}
+
 // WithValidator sets an validator to validate results for a measurement
 func WithValidator(v ResultValidator) MeasurementOpt {
 	return func(r *Measurement) {
@@ -27,13 +49,24 @@ func WithValidator(v ResultValidator) MeasurementOpt {
 	}
 }
 
+// WithMinFirmware skips results of probes running a firmware older than fw. Skipped results are counted in `ResultsSkippedFirmware`.
+func WithMinFirmware(id, measurementType string, fw int) MeasurementOpt {
+	return func(r *Measurement) {
+		r.minFirmware = fw
+		r.skippedFirmware = ResultsSkippedFirmware.WithLabelValues(id, measurementType)
+	}
+}
+
 // Measurement handles measurement results and converts to metrics
 type Measurement struct {
-	latest     map[int]*measurement.Result
-	probes     map[int]*probe.Probe
-	histograms []Histogram
-	exporter   Exporter
-	validator  ResultValidator
+	latest          map[int]*measurement.Result
+	probes          map[int]*probe.Probe
+	histograms      []Histogram
+	aggregators     []Aggregator
+	exporter        Exporter
+	validator       ResultValidator
+	minFirmware     int
+	skippedFirmware prometheus.Counter
 }
 
 // NewMeasurement returns a new instance of `Measurement`
@@ -54,6 +87,11 @@ func NewMeasurement(exporter Exporter, opts ...MeasurementOpt) *Measurement {
 
 // Add adds an result to a measurement
 func (r *Measurement) Add(m *measurement.Result, probe *probe.Probe) {
+	if m.Fw() < r.minFirmware {
+		r.skippedFirmware.Inc()
+		return
+	}
+
 	if r.validator != nil && !r.validator.IsValid(m, probe) {
 		return
 	}
@@ -66,6 +104,18 @@ func (r *Measurement) Add(m *measurement.Result, probe *probe.Probe) {
 	}
 }
 
+// Probes returns the probes the latest results of the measurement originate from
+func (r *Measurement) Probes() []*probe.Probe {
+	probes := make([]*probe.Probe, 0, len(r.probes))
+	for _, p := range r.probes {
+		if p != nil {
+			probes = append(probes, p)
+		}
+	}
+
+	return probes
+}
+
 // Describe describes all metrics for the `Measurement`
 func (r *Measurement) Describe(ch chan<- *prometheus.Desc) {
 	r.exporter.Describe(ch)
@@ -73,17 +123,45 @@ func (r *Measurement) Describe(ch chan<- *prometheus.Desc) {
 	for _, h := range r.histograms {
 		h.Hist().Describe(ch)
 	}
+
+	for _, a := range r.aggregators {
+		a.Describe(ch)
+	}
 }
 
 // Collect collects metrics for the `Measurement`
 func (r *Measurement) Collect(ch chan<- prometheus.Metric) {
 	for _, v := range r.latest {
-		r.exporter.Export(v, r.probes[v.PrbId()], ch)
+		r.export(v, ch)
 	}
 
 	for _, h := range r.histograms {
 		h.Hist().Collect(ch)
 	}
+
+	if len(r.aggregators) > 0 {
+		results := make([]*measurement.Result, 0, len(r.latest))
+		for _, v := range r.latest {
+			results = append(results, v)
+		}
+
+		for _, a := range r.aggregators {
+			a.Aggregate(results, ch)
+		}
+	}
+}
+
+// export exports the metrics for a single result. A panic (e.g. caused by a malformed result) is logged and counted
+// to not break the collection of the metrics of all other results.
+func (r *Measurement) export(res *measurement.Result, ch chan<- prometheus.Metric) {
+	defer func() {
+		if err := recover(); err != nil {
+			ExportPanics.Inc()
+			log.Errorf("panic while exporting result of measurement %d for probe %d: %v", res.MsmId(), res.PrbId(), err)
+		}
+	}()
+
+	r.exporter.Export(res, r.probes[res.PrbId()], ch)
 }
 
 func IpVersionForMeasurement(r *measurement.Result) string {
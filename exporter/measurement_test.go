@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDesc = prometheus.NewDesc("atlas_test_result", "Test", []string{"probe"}, nil)
+
+type panicExporter struct {
+	panicProbe int
+}
+
+func (e *panicExporter) Export(res *measurement.Result, p *probe.Probe, ch chan<- prometheus.Metric) {
+	if p.ID == e.panicProbe {
+		panic("malformed result")
+	}
+
+	ch <- prometheus.MustNewConstMetric(testDesc, prometheus.GaugeValue, 1, strconv.Itoa(p.ID))
+}
+
+func (e *panicExporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- testDesc
+}
+
+func TestCollectRecoversPanics(t *testing.T) {
+	m := NewMeasurement(&panicExporter{panicProbe: 2})
+	for _, id := range []int{1, 2, 3} {
+		res := &measurement.Result{}
+		if err := json.Unmarshal([]byte(`{"type":"ping","msm_id":1,"prb_id":`+strconv.Itoa(id)+`}`), res); err != nil {
+			t.Fatal(err)
+		}
+
+		m.Add(res, &probe.Probe{ID: id})
+	}
+
+	before := testutil.ToFloat64(ExportPanics)
+
+	expected := `
+# HELP atlas_test_result Test
+# TYPE atlas_test_result gauge
+atlas_test_result{probe="1"} 1
+atlas_test_result{probe="3"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_test_result")
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(ExportPanics))
+}
+
+func TestMinFirmware(t *testing.T) {
+	// measurements are created on every scrape in request mode, so the counter must not start over
+	for i := 0; i < 2; i++ {
+		m := NewMeasurement(&panicExporter{}, WithMinFirmware("1001", "ping", 5020))
+		for _, fw := range []int{4790, 5020, 5080} {
+			res := &measurement.Result{}
+			if err := json.Unmarshal([]byte(`{"type":"ping","msm_id":1001,"prb_id":`+strconv.Itoa(fw)+`,"fw":`+strconv.Itoa(fw)+`}`), res); err != nil {
+				t.Fatal(err)
+			}
+
+			m.Add(res, &probe.Probe{ID: fw})
+		}
+
+		assert.Len(t, m.Probes(), 2)
+	}
+
+	expected := `
+# HELP atlas_results_skipped_firmware Number of results skipped since the firmware of the probe is older than the configured minimum
+# TYPE atlas_results_skipped_firmware counter
+atlas_results_skipped_firmware{measurement="1001",measurement_type="ping"} 2
+`
+	err := testutil.CollectAndCompare(ResultsSkippedFirmware, strings.NewReader(expected))
+	assert.NoError(t, err)
+}
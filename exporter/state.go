@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// StateStore keeps state per key between scrapes. Entries not updated within the TTL are evicted.
+type StateStore[T any] struct {
+	items     map[string]*stateItem[T]
+	mutex     sync.Mutex
+	ttl       time.Duration
+	lastClean time.Time
+}
+
+type stateItem[T any] struct {
+	expires time.Time
+	value   T
+}
+
+// NewStateStore creates a state store
+func NewStateStore[T any](ttl time.Duration) *StateStore[T] {
+	return &StateStore[T]{
+		items:     make(map[string]*stateItem[T]),
+		ttl:       ttl,
+		lastClean: time.Now(),
+	}
+}
+
+// Update replaces the state for key by the result of f (found is false if there was no state yet) and returns it
+func (s *StateStore[T]) Update(key string, f func(state T, found bool) T) T {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.cleanUp(now)
+
+	item, found := s.items[key]
+	if !found || now.After(item.expires) {
+		var empty T
+		item = &stateItem[T]{value: empty}
+		found = false
+	}
+
+	item.value = f(item.value, found)
+	item.expires = now.Add(s.ttl)
+	s.items[key] = item
+
+	return item.value
+}
+
+// Len returns the number of keys in the store
+func (s *StateStore[T]) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.items)
+}
+
+func (s *StateStore[T]) cleanUp(now time.Time) {
+	if now.Sub(s.lastClean) < s.ttl {
+		return
+	}
+
+	for k, v := range s.items {
+		if now.After(v.expires) {
+			delete(s.items, k)
+		}
+	}
+
+	s.lastClean = now
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"time"
+)
+
+const failureStateTTL = 24 * time.Hour
+
+var failures = NewStateStore[failureState](failureStateTTL)
+
+type failureState struct {
+	timestamp int
+	count     int
+}
+
+// Success returns the value of the success metric for a result. With a threshold greater than 1
+// success is only reported as 0 after the given number of consecutive failed results for the key (e.g. measurement and probe).
+func Success(key string, timestamp int, ok bool, threshold int) float64 {
+	if threshold <= 1 {
+		if ok {
+			return 1
+		}
+
+		return 0
+	}
+
+	s := failures.Update(key, func(s failureState, found bool) failureState {
+		if found && s.timestamp == timestamp {
+			return s
+		}
+
+		s.timestamp = timestamp
+		if ok {
+			s.count = 0
+		} else {
+			s.count++
+		}
+
+		return s
+	})
+
+	if s.count >= threshold {
+		return 0
+	}
+
+	return 1
+}
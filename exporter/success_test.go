@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccessWithoutThreshold(t *testing.T) {
+	assert.Equal(t, float64(1), Success("immediate", 1, true, 0))
+	assert.Equal(t, float64(0), Success("immediate", 2, false, 0))
+	assert.Equal(t, float64(0), Success("immediate", 3, false, 1))
+}
+
+func TestSuccessWithThreshold(t *testing.T) {
+	key := "threshold"
+
+	assert.Equal(t, float64(1), Success(key, 1, false, 3), "1st failure")
+	assert.Equal(t, float64(1), Success(key, 1, false, 3), "same result scraped again")
+	assert.Equal(t, float64(1), Success(key, 2, false, 3), "2nd failure")
+	assert.Equal(t, float64(0), Success(key, 3, false, 3), "3rd failure")
+	assert.Equal(t, float64(0), Success(key, 3, false, 3), "same result scraped again")
+	assert.Equal(t, float64(1), Success(key, 4, true, 3), "success resets counter")
+	assert.Equal(t, float64(1), Success(key, 5, false, 3), "1st failure after success")
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"net"
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/lookup"
+)
+
+type asyncResolver struct {
+	cache *lookup.Cache[Location]
+}
+
+// NewAsyncResolver returns a resolver caching the results of r (including addresses not located). Addresses not cached
+// are resolved in the background, `lookup.ErrPending` is returned until the lookup completed. Failed lookups are cached
+// for `lookup.DefaultNegativeTTL`.
+func NewAsyncResolver(r Resolver, ttl time.Duration) Resolver {
+	return &asyncResolver{
+		cache: lookup.NewCache(r.Locate, ttl, lookup.DefaultNegativeTTL),
+	}
+}
+
+// Locate returns the location of the IP address
+func (r *asyncResolver) Locate(ip net.IP) (Location, error) {
+	return r.cache.Get(ip)
+}
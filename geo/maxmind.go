@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// recordReader looks up the record of an IP address in a MaxMind database
+type recordReader interface {
+	Lookup(ip net.IP, result interface{}) error
+}
+
+type maxMindResolver struct {
+	reader recordReader
+}
+
+type locationRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// NewMaxMindResolver returns a resolver using a local MaxMind city or country database (e.g. GeoLite2-City.mmdb)
+func NewMaxMindResolver(path string) (Resolver, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &maxMindResolver{reader: r}, nil
+}
+
+// Locate returns the location of the IP address (the city is only known when using a city database)
+func (r *maxMindResolver) Locate(ip net.IP) (Location, error) {
+	rec := &locationRecord{}
+	err := r.reader.Lookup(ip, rec)
+	if err != nil {
+		return Location{}, err
+	}
+
+	return Location{Country: rec.Country.ISOCode, City: rec.City.Names["en"]}, nil
+}
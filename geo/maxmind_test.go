@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticRecords map[string]Location
+
+func (r staticRecords) Lookup(ip net.IP, result interface{}) error {
+	rec := result.(*locationRecord)
+	if l, found := r[ip.String()]; found {
+		rec.Country.ISOCode = l.Country
+		if l.City != "" {
+			rec.City.Names = map[string]string{"de": "Frankfurt am Main", "en": l.City}
+		}
+	}
+
+	return nil
+}
+
+func TestMaxMindResolver(t *testing.T) {
+	r := &maxMindResolver{reader: staticRecords{
+		"193.0.0.1":    {Country: "NL"},
+		"198.51.100.1": {Country: "DE", City: "Frankfurt am Main"},
+	}}
+
+	l, err := r.Locate(net.ParseIP("193.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, Location{Country: "NL"}, l, "country database")
+
+	l, err = r.Locate(net.ParseIP("198.51.100.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, Location{Country: "DE", City: "Frankfurt am Main"}, l)
+
+	l, err = r.Locate(net.ParseIP("203.0.113.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, Location{}, l, "not located")
+}
+
+func TestNewMaxMindResolverMissingFile(t *testing.T) {
+	_, err := NewMaxMindResolver("testdata/does-not-exist.mmdb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"net"
+)
+
+// Location is the location of an IP address
+type Location struct {
+	// Country is the ISO 3166 country code (empty if not located)
+	Country string
+
+	// City is the English name of the city (empty if not located on city level)
+	City string
+}
+
+// Resolver resolves the location of an IP address
+type Resolver interface {
+	// Locate returns the location of the IP address
+	Locate(ip net.IP) (Location, error)
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	ripeStatURL     = "https://stat.ripe.net/data/maxmind-geo-lite/data.json"
+	ripeStatTimeout = 10 * time.Second
+)
+
+type ripeStatResolver struct {
+	client *http.Client
+}
+
+type geoLite struct {
+	Data struct {
+		LocatedResources []struct {
+			Locations []struct {
+				Country string `json:"country"`
+				City    string `json:"city"`
+			} `json:"locations"`
+		} `json:"located_resources"`
+	} `json:"data"`
+}
+
+// NewRIPEstatResolver returns a resolver using the MaxMind GeoLite2 data provided by the RIPEstat Data API
+func NewRIPEstatResolver() Resolver {
+	return &ripeStatResolver{
+		client: &http.Client{Timeout: ripeStatTimeout},
+	}
+}
+
+// Locate returns the location of the IP address
+func (r *ripeStatResolver) Locate(ip net.IP) (Location, error) {
+	u := fmt.Sprintf("%s?resource=%s&sourceapp=atlas_exporter", ripeStatURL, ip.String())
+
+	resp, err := r.client.Get(u)
+	if err != nil {
+		return Location{}, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("could not resolve location of %s: %s", ip, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Location{}, err
+	}
+
+	return locationFromGeoLite(body)
+}
+
+func locationFromGeoLite(b []byte) (Location, error) {
+	info := &geoLite{}
+	err := json.Unmarshal(b, info)
+	if err != nil {
+		return Location{}, err
+	}
+
+	for _, r := range info.Data.LocatedResources {
+		for _, l := range r.Locations {
+			if l.Country != "" {
+				return Location{Country: l.Country, City: l.City}, nil
+			}
+		}
+	}
+
+	return Location{}, nil
+}
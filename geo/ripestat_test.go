@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocationFromGeoLite(t *testing.T) {
+	l, err := locationFromGeoLite([]byte(`{
+		"data": {
+			"located_resources": [
+				{"resource": "193.0.0.0/21", "locations": [{"country": "NL", "city": "Amsterdam", "covered_percentage": 100}]}
+			]
+		}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Location{Country: "NL", City: "Amsterdam"}, l)
+
+	l, err = locationFromGeoLite([]byte(`{"data": {"located_resources": []}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Location{}, l)
+}
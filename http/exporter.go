@@ -6,13 +6,26 @@ import (
 	"strconv"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 )
 
 var (
-	labels         []string
+	constLabels = prometheus.Labels{"measurement_type": sub}
+
+	labels = []string{"measurement", "probe", "dst_addr", "asn", "ip_version", "uri", "method", "country_code", "lat", "long"}
+)
+
+type httpExporter struct {
+	id                  string
+	coordinatePrecision int
+	failureThreshold    int
+	srcAddrLabel        bool
+	labelSet            *exporter.LabelSet
+
 	resultDesc     *prometheus.Desc
 	httpVerDesc    *prometheus.Desc
 	bodySizeDesc   *prometheus.Desc
@@ -20,28 +33,43 @@ var (
 	rttDesc        *prometheus.Desc
 	dnsErrDesc     *prometheus.Desc
 	successDesc    *prometheus.Desc
-)
+	upDesc         *prometheus.Desc
+}
 
-func init() {
-	labels = []string{"measurement", "probe", "dst_addr", "asn", "ip_version", "uri", "method", "country_code", "lat", "long"}
+// newHTTPExporter returns a new exporter (the labels of the metrics depend on the config)
+func newHTTPExporter(id string, cfg *config.Config) *httpExporter {
+	e := &httpExporter{
+		id:                  id,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		failureThreshold:    cfg.FailureThreshold,
+		srcAddrLabel:        cfg.SrcAddrLabel,
+	}
 
-	successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", labels, nil)
-	resultDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "result"), "Code returned from http server", labels, nil)
-	httpVerDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "version"), "HTTP version used for the request", labels, nil)
-	bodySizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "body_size"), "Body size in bytes", labels, nil)
-	headerSizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "header_size"), "Header size in bytes", labels, nil)
-	rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", labels, nil)
-	dnsErrDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "dns_error"), "A DNS error occurred (0 if not)", labels, nil)
-}
+	names := exporter.WithLabels(labels)
+	if e.srcAddrLabel {
+		names = append(names, "src_addr")
+	}
+	e.labelSet = exporter.NewLabelSet(names, cfg)
 
-type httpExporter struct {
-	id string
+	l := e.labelSet.Names()
+	e.successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", l, constLabels)
+	if cfg.UpMetric {
+		e.upDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "up"), "Destination was reachable (same as success)", l, constLabels)
+	}
+	e.resultDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "result"), "Code returned from http server", l, constLabels)
+	e.httpVerDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "version"), "HTTP version used for the request", l, constLabels)
+	e.bodySizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "body_size"), "Body size in bytes", l, constLabels)
+	e.headerSizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "header_size"), "Header size in bytes", l, constLabels)
+	e.rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", l, constLabels)
+	e.dnsErrDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "dns_error"), "A DNS error occurred (0 if not)", l, constLabels)
+
+	return e
 }
 
 // Export exports metrics for Prometheus
 func (m *httpExporter) Export(res *measurement.Result, probe *probe.Probe, ch chan<- prometheus.Metric) {
 	for _, h := range res.HttpResults() {
-		labelValues := []string{
+		values := []string{
 			m.id,
 			strconv.Itoa(probe.ID),
 			h.DstAddr(),
@@ -50,10 +78,16 @@ func (m *httpExporter) Export(res *measurement.Result, probe *probe.Probe, ch ch
 			res.Uri(),
 			h.Method(),
 			probe.CountryCode,
-			probe.Latitude(),
-			probe.Longitude(),
+			probe.LatitudeWithPrecision(m.coordinatePrecision),
+			probe.LongitudeWithPrecision(m.coordinatePrecision),
 		}
 
+		if m.srcAddrLabel {
+			values = append(values, h.SrcAddr())
+		}
+
+		labelValues := m.labelSet.Values(values, probe, h.Af())
+
 		dnsError := 0
 		if len(h.Dnserr()) > 0 {
 			dnsError = 1
@@ -64,28 +98,37 @@ func (m *httpExporter) Export(res *measurement.Result, probe *probe.Probe, ch ch
 			log.Errorf("error parsing http version %s: %v", h.Ver(), err)
 		}
 
-		ch <- prometheus.MustNewConstMetric(resultDesc, prometheus.GaugeValue, float64(h.Res()), labelValues...)
-		ch <- prometheus.MustNewConstMetric(httpVerDesc, prometheus.GaugeValue, httpVer, labelValues...)
-		ch <- prometheus.MustNewConstMetric(bodySizeDesc, prometheus.GaugeValue, float64(h.Bsize()), labelValues...)
-		ch <- prometheus.MustNewConstMetric(headerSizeDesc, prometheus.GaugeValue, float64(h.Hsize()), labelValues...)
-		ch <- prometheus.MustNewConstMetric(dnsErrDesc, prometheus.GaugeValue, float64(dnsError), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.resultDesc, prometheus.GaugeValue, float64(h.Res()), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.httpVerDesc, prometheus.GaugeValue, httpVer, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.bodySizeDesc, prometheus.GaugeValue, float64(h.Bsize()), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.headerSizeDesc, prometheus.GaugeValue, float64(h.Hsize()), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.dnsErrDesc, prometheus.GaugeValue, float64(dnsError), labelValues...)
+
+		key := m.id + "/" + strconv.Itoa(probe.ID) + "/" + h.DstAddr()
+		success := exporter.Success(key, res.Timestamp(), h.Rt() > 0, m.failureThreshold)
+		ch <- prometheus.MustNewConstMetric(m.successDesc, prometheus.GaugeValue, success, labelValues...)
+
+		if m.upDesc != nil {
+			ch <- prometheus.MustNewConstMetric(m.upDesc, prometheus.GaugeValue, success, labelValues...)
+		}
 
 		if h.Rt() > 0 {
-			ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 1, labelValues...)
-			ch <- prometheus.MustNewConstMetric(rttDesc, prometheus.GaugeValue, h.Rt(), labelValues...)
-		} else {
-			ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
+			ch <- prometheus.MustNewConstMetric(m.rttDesc, prometheus.GaugeValue, h.Rt(), labelValues...)
 		}
 	}
 }
 
 // Describe exports metric descriptions for Prometheus
 func (m *httpExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- successDesc
-	ch <- resultDesc
-	ch <- httpVerDesc
-	ch <- bodySizeDesc
-	ch <- headerSizeDesc
-	ch <- rttDesc
-	ch <- dnsErrDesc
+	ch <- m.successDesc
+	ch <- m.resultDesc
+	ch <- m.httpVerDesc
+	ch <- m.bodySizeDesc
+	ch <- m.headerSizeDesc
+	ch <- m.rttDesc
+	ch <- m.dnsErrDesc
+
+	if m.upDesc != nil {
+		ch <- m.upDesc
+	}
 }
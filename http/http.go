@@ -22,5 +22,9 @@ func NewMeasurement(id, ipVersion string, cfg *config.Config) *exporter.Measurem
 		opts = append(opts, exporter.WithValidator(&exporter.DefaultResultValidator{}))
 	}
 
-	return exporter.NewMeasurement(&httpExporter{id}, opts...)
+	if cfg.MinFirmware > 0 {
+		opts = append(opts, exporter.WithMinFirmware(id, sub, cfg.MinFirmware))
+	}
+
+	return exporter.NewMeasurement(newHTTPExporter(id, cfg), opts...)
 }
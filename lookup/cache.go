@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package lookup
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultNegativeTTL is the time failed lookups are cached before the address is looked up again
+	DefaultNegativeTTL = 5 * time.Minute
+
+	workerCount = 4
+	queueSize   = 1000
+)
+
+// ErrPending is returned as long as the lookup of an address has not completed
+var ErrPending = errors.New("lookup pending")
+
+// Func looks up the value for an IP address (e.g. by querying a remote API)
+type Func[T any] func(ip net.IP) (T, error)
+
+// Cache caches the values looked up for IP addresses. Addresses not cached yet are looked up in the background
+// by a fixed number of workers, so callers (e.g. collecting metrics during a scrape) never wait for the source.
+// Failed lookups are cached for a shorter time to not query a failing source again on every call.
+type Cache[T any] struct {
+	lookup      Func[T]
+	items       map[string]*item[T]
+	mutex       sync.Mutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	queue       chan net.IP
+	startOnce   sync.Once
+	lastClean   time.Time
+}
+
+type item[T any] struct {
+	expires time.Time
+	value   T
+	err     error
+}
+
+// NewCache returns a cache for the values returned by f. Values are cached for ttl, errors for negativeTTL.
+func NewCache[T any](f Func[T], ttl, negativeTTL time.Duration) *Cache[T] {
+	return &Cache[T]{
+		lookup:      f,
+		items:       make(map[string]*item[T]),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		queue:       make(chan net.IP, queueSize),
+		lastClean:   time.Now(),
+	}
+}
+
+// Get returns the cached value (or error) for the IP address. If the address is not cached, a lookup is queued
+// and ErrPending is returned until it completed.
+func (c *Cache[T]) Get(ip net.IP) (T, error) {
+	c.startOnce.Do(c.startWorkers)
+
+	key := ip.String()
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	it, found := c.items[key]
+	if found && now.Before(it.expires) {
+		return it.value, it.err
+	}
+
+	var empty T
+	select {
+	case c.queue <- ip:
+		// the pending entry expires if the lookup could not be completed, so the address is queued again
+		c.items[key] = &item[T]{expires: now.Add(c.negativeTTL), err: ErrPending}
+	default:
+		// queue is full, the address is queued again on the next call
+	}
+
+	return empty, ErrPending
+}
+
+func (c *Cache[T]) startWorkers() {
+	for i := 0; i < workerCount; i++ {
+		go c.work()
+	}
+}
+
+func (c *Cache[T]) work() {
+	for ip := range c.queue {
+		v, err := c.lookup(ip)
+		if err != nil {
+			log.Errorf("lookup for %s failed: %v", ip, err)
+		}
+
+		c.set(ip.String(), v, err)
+	}
+}
+
+func (c *Cache[T]) set(key string, v T, err error) {
+	now := time.Now()
+	ttl := c.ttl
+	if err != nil {
+		ttl = c.negativeTTL
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cleanUp(now)
+	c.items[key] = &item[T]{expires: now.Add(ttl), value: v, err: err}
+}
+
+func (c *Cache[T]) cleanUp(now time.Time) {
+	if now.Sub(c.lastClean) < c.negativeTTL {
+		return
+	}
+
+	c.lastClean = now
+	for k, v := range c.items {
+		if now.After(v.expires) {
+			delete(c.items, k)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package lookup
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var calls atomic.Int32
+	c := NewCache(func(ip net.IP) (string, error) {
+		calls.Add(1)
+		return "value for " + ip.String(), nil
+	}, time.Hour, time.Minute)
+
+	ip := net.ParseIP("192.0.2.1")
+	_, err := c.Get(ip)
+	assert.ErrorIs(t, err, ErrPending)
+
+	assert.Eventually(t, func() bool {
+		_, err := c.Get(ip)
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	v, err := c.Get(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "value for 192.0.2.1", v)
+	assert.Equal(t, int32(1), calls.Load(), "address must be looked up once")
+}
+
+func TestCacheError(t *testing.T) {
+	var calls atomic.Int32
+	errLookup := errors.New("service unavailable")
+	c := NewCache(func(ip net.IP) (int, error) {
+		calls.Add(1)
+		return 0, errLookup
+	}, time.Hour, time.Minute)
+
+	ip := net.ParseIP("192.0.2.1")
+	assert.Eventually(t, func() bool {
+		_, err := c.Get(ip)
+		return errors.Is(err, errLookup)
+	}, time.Second, time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		_, err := c.Get(ip)
+		assert.ErrorIs(t, err, errLookup)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "failed lookup must be cached")
+}
+
+func TestCacheNegativeTTL(t *testing.T) {
+	var calls atomic.Int32
+	c := NewCache(func(ip net.IP) (int, error) {
+		if calls.Add(1) == 1 {
+			return 0, errors.New("service unavailable")
+		}
+
+		return 64496, nil
+	}, time.Hour, 10*time.Millisecond)
+
+	ip := net.ParseIP("192.0.2.1")
+	assert.Eventually(t, func() bool {
+		v, err := c.Get(ip)
+		return err == nil && v == 64496
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/czerwonk/atlas_exporter/atlas"
 	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -46,6 +47,10 @@ var (
 	tlsEnabled          = flag.Bool("tls.enabled", false, "Enables TLS")
 	tlsCertChainPath    = flag.String("tls.cert-file", "", "Path to TLS cert file")
 	tlsKeyPath          = flag.String("tls.key-file", "", "Path to TLS key file")
+	validate            = flag.Bool("validate", false, "Validates the config file and the configured measurements and exits")
+	pushURL             = flag.String("push.url", "", "URL of a Prometheus Pushgateway to push metrics of the configured measurements to (disabled if empty)")
+	pushInterval        = flag.Duration("push.interval", streamTimeout, "Interval in which metrics are pushed to the Pushgateway")
+	pushJob             = flag.String("push.job", "atlas_exporter", "Job name used when pushing metrics to the Pushgateway")
 	cfg                 *config.Config
 	strategy            atlas.Strategy
 )
@@ -74,9 +79,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *validate {
+		os.Exit(validateConfig())
+	}
+
+	if errs := checkConfig(); len(errs) > 0 {
+		for _, err := range errs {
+			log.Error(err)
+		}
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	if *streaming {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
 		strategy = atlas.NewStreamingStrategy(ctx, cfg, *streamingBufferSize, *streamingTimeout)
 	} else {
 		strategy = atlas.NewRequestStrategy(cfg, *workerCount)
@@ -86,7 +103,7 @@ func main() {
 		http.DefaultServeMux = http.NewServeMux()
 	}
 
-	startServer()
+	startServer(ctx)
 }
 
 func printVersion() {
@@ -117,7 +134,7 @@ func loadConfig() error {
 	return nil
 }
 
-func startServer() {
+func startServer(ctx context.Context) {
 	log.Infof("Starting atlas exporter (Version: %s)", version)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
@@ -140,6 +157,10 @@ func startServer() {
 	log.Infof("Cache cleanup interval: %v", time.Duration(*cacheCleanUp)*time.Second)
 	atlas.InitCache(time.Duration(*cacheTTL)*time.Second, time.Duration(*cacheCleanUp)*time.Second)
 
+	if len(*pushURL) > 0 {
+		startPushing(ctx)
+	}
+
 	log.Infof("Listening for %s on %s (TLS: %v)", *metricsPath, *listenAddress, *tlsEnabled)
 	if *tlsEnabled {
 		log.Fatal(http.ListenAndServeTLS(*listenAddress, *tlsCertChainPath, *tlsKeyPath, nil))
@@ -199,9 +220,12 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) error {
 		reg.MustRegister(goCollector)
 	}
 
+	reg.MustRegister(exporter.ExportPanics, exporter.ResultsSkippedFirmware)
+
 	if len(measurements) > 0 {
 		c := newCollector(measurements)
 		reg.MustRegister(c)
+		reg.MustRegister(newProbeInfoCollector(measurements, cfg.LatLongPrecision()))
 	}
 
 	l := log.New()
@@ -6,36 +6,51 @@ import (
 	"strconv"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	labels             []string
+	constLabels = prometheus.Labels{"measurement_type": sub}
+
+	labels = []string{"measurement", "probe", "dst_addr", "dst_name", "asn", "ip_version", "country_code", "lat", "long"}
+)
+
+type ntpExporter struct {
+	id                  string
+	coordinatePrecision int
+	labelSet            *exporter.LabelSet
+
 	pollDesc           *prometheus.Desc
 	precisionDesc      *prometheus.Desc
 	roolDelayDesc      *prometheus.Desc
 	rootDispersionDesc *prometheus.Desc
 	ntpVersionDesc     *prometheus.Desc
-)
+}
 
-func init() {
-	labels = []string{"measurement", "probe", "dst_addr", "dst_name", "asn", "ip_version", "country_code", "lat", "long"}
+// newNTPExporter returns a new exporter (the labels of the metrics depend on the config)
+func newNTPExporter(id string, cfg *config.Config) *ntpExporter {
+	e := &ntpExporter{
+		id:                  id,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		labelSet:            exporter.NewLabelSet(labels, cfg),
+	}
 
-	pollDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "poll"), "Poll", labels, nil)
-	precisionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "precision"), "Precision", labels, nil)
-	roolDelayDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "root_delay"), "Root delay", labels, nil)
-	rootDispersionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "root_dispersion"), "Root dispersion", labels, nil)
-	ntpVersionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "ntp_version"), "NTP Version", labels, nil)
-}
+	l := e.labelSet.Names()
+	e.pollDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "poll"), "Poll", l, constLabels)
+	e.precisionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "precision"), "Precision", l, constLabels)
+	e.roolDelayDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "root_delay"), "Root delay", l, constLabels)
+	e.rootDispersionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "root_dispersion"), "Root dispersion", l, constLabels)
+	e.ntpVersionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "ntp_version"), "NTP Version", l, constLabels)
 
-type ntpExporter struct {
-	id string
+	return e
 }
 
 // Export exports a prometheus metric
 func (m *ntpExporter) Export(res *measurement.Result, probe *probe.Probe, ch chan<- prometheus.Metric) {
-	labelValues := []string{
+	labelValues := m.labelSet.Values([]string{
 		m.id,
 		strconv.Itoa(probe.ID),
 		res.DstAddr(),
@@ -43,22 +58,22 @@ func (m *ntpExporter) Export(res *measurement.Result, probe *probe.Probe, ch cha
 		strconv.Itoa(probe.ASNForIPVersion(res.Af())),
 		strconv.Itoa(res.Af()),
 		probe.CountryCode,
-		probe.Latitude(),
-		probe.Longitude(),
-	}
+		probe.LatitudeWithPrecision(m.coordinatePrecision),
+		probe.LongitudeWithPrecision(m.coordinatePrecision),
+	}, probe, res.Af())
 
-	ch <- prometheus.MustNewConstMetric(pollDesc, prometheus.GaugeValue, res.Poll(), labelValues...)
-	ch <- prometheus.MustNewConstMetric(precisionDesc, prometheus.GaugeValue, res.Precision(), labelValues...)
-	ch <- prometheus.MustNewConstMetric(roolDelayDesc, prometheus.GaugeValue, res.RootDelay(), labelValues...)
-	ch <- prometheus.MustNewConstMetric(rootDispersionDesc, prometheus.GaugeValue, res.RootDispersion(), labelValues...)
-	ch <- prometheus.MustNewConstMetric(ntpVersionDesc, prometheus.GaugeValue, float64(res.Version()), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.pollDesc, prometheus.GaugeValue, res.Poll(), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.precisionDesc, prometheus.GaugeValue, res.Precision(), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.roolDelayDesc, prometheus.GaugeValue, res.RootDelay(), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.rootDispersionDesc, prometheus.GaugeValue, res.RootDispersion(), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.ntpVersionDesc, prometheus.GaugeValue, float64(res.Version()), labelValues...)
 }
 
 // Describe exports metric descriptions for Prometheus
 func (m *ntpExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- pollDesc
-	ch <- precisionDesc
-	ch <- roolDelayDesc
-	ch <- rootDispersionDesc
-	ch <- ntpVersionDesc
+	ch <- m.pollDesc
+	ch <- m.precisionDesc
+	ch <- m.roolDelayDesc
+	ch <- m.rootDispersionDesc
+	ch <- m.ntpVersionDesc
 }
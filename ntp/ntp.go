@@ -20,5 +20,9 @@ func NewMeasurement(id string, cfg *config.Config) *exporter.Measurement {
 		opts = append(opts, exporter.WithValidator(&exporter.DefaultResultValidator{}))
 	}
 
-	return exporter.NewMeasurement(&ntpExporter{id}, opts...)
+	if cfg.MinFirmware > 0 {
+		opts = append(opts, exporter.WithMinFirmware(id, sub, cfg.MinFirmware))
+	}
+
+	return exporter.NewMeasurement(newNTPExporter(id, cfg), opts...)
 }
@@ -6,13 +6,29 @@ import (
 	"strconv"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	labels         []string
+	constLabels = prometheus.Labels{"measurement_type": sub}
+
+	labels = []string{"measurement", "probe", "dst_addr", "dst_name", "asn", "ip_version", "country_code", "lat", "long"}
+)
+
+type pingExporter struct {
+	id                  string
+	coordinatePrecision int
+	failureThreshold    int
+	packetRTT           bool
+	sizeLabel           bool
+	srcAddrLabel        bool
+	labelSet            *exporter.LabelSet
+
 	successDesc    *prometheus.Desc
+	upDesc         *prometheus.Desc
 	minLatencyDesc *prometheus.Desc
 	maxLatencyDesc *prometheus.Desc
 	avgLatencyDesc *prometheus.Desc
@@ -21,28 +37,54 @@ var (
 	dupDesc        *prometheus.Desc
 	ttlDesc        *prometheus.Desc
 	sizeDesc       *prometheus.Desc
-)
-
-type pingExporter struct {
-	id string
+	jitterDesc     *prometheus.Desc
+	lossDesc       *prometheus.Desc
+	packetRttDesc  *prometheus.Desc
 }
 
-func init() {
-	labels = []string{"measurement", "probe", "dst_addr", "dst_name", "asn", "ip_version", "country_code", "lat", "long"}
-	successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", labels, nil)
-	minLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "min_latency"), "Minimum latency", labels, nil)
-	maxLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "max_latency"), "Maximum latency", labels, nil)
-	avgLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "avg_latency"), "Average latency", labels, nil)
-	sentDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "sent"), "Number of sent icmp requests", labels, nil)
-	rcvdDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "received"), "Number of received icmp repsponses", labels, nil)
-	dupDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "dup"), "Number of duplicate icmp repsponses", labels, nil)
-	ttlDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "ttl"), "Time-to-live field in the response", labels, nil)
-	sizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "size"), "Size of ICMP packet", labels, nil)
+// newPingExporter returns a new exporter (the labels of the metrics depend on the config)
+func newPingExporter(id string, cfg *config.Config) *pingExporter {
+	e := &pingExporter{
+		id:                  id,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		failureThreshold:    cfg.FailureThreshold,
+		packetRTT:           cfg.Ping.PacketRTT,
+		sizeLabel:           cfg.Ping.SizeLabel,
+		srcAddrLabel:        cfg.SrcAddrLabel,
+	}
+
+	names := exporter.WithLabels(labels)
+	if e.sizeLabel {
+		names = append(names, "size")
+	}
+	if e.srcAddrLabel {
+		names = append(names, "src_addr")
+	}
+	e.labelSet = exporter.NewLabelSet(names, cfg)
+
+	l := e.labelSet.Names()
+	e.successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", l, constLabels)
+	if cfg.UpMetric {
+		e.upDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "up"), "Destination was reachable (same as success)", l, constLabels)
+	}
+	e.minLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "min_latency"), "Minimum latency", l, constLabels)
+	e.maxLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "max_latency"), "Maximum latency", l, constLabels)
+	e.avgLatencyDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "avg_latency"), "Average latency", l, constLabels)
+	e.sentDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "sent"), "Number of sent icmp requests", l, constLabels)
+	e.rcvdDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "received"), "Number of received icmp repsponses", l, constLabels)
+	e.dupDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "dup"), "Number of duplicate icmp repsponses", l, constLabels)
+	e.ttlDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "ttl"), "Time-to-live field in the response", l, constLabels)
+	e.sizeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "size"), "Size of ICMP packet", l, constLabels)
+	e.jitterDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "jitter"), "Mean absolute difference of the RTTs of consecutive replies", l, constLabels)
+	e.lossDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "loss_ratio"), "Ratio of icmp requests without response", l, constLabels)
+	e.packetRttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "packet_rtt"), "Round trip time in ms of the reply", exporter.WithLabels(l, "seq"), constLabels)
+
+	return e
 }
 
 // Export exports a prometheus metric
 func (m *pingExporter) Export(res *measurement.Result, probe *probe.Probe, ch chan<- prometheus.Metric) {
-	labelValues := []string{
+	values := []string{
 		m.id,
 		strconv.Itoa(probe.ID),
 		res.DstAddr(),
@@ -50,35 +92,82 @@ func (m *pingExporter) Export(res *measurement.Result, probe *probe.Probe, ch ch
 		strconv.Itoa(probe.ASNForIPVersion(res.Af())),
 		strconv.Itoa(res.Af()),
 		probe.CountryCode,
-		probe.Latitude(),
-		probe.Longitude(),
+		probe.LatitudeWithPrecision(m.coordinatePrecision),
+		probe.LongitudeWithPrecision(m.coordinatePrecision),
+	}
+
+	if m.sizeLabel {
+		values = append(values, strconv.Itoa(res.Size()))
+	}
+
+	if m.srcAddrLabel {
+		values = append(values, res.SrcAddr())
+	}
+
+	labelValues := m.labelSet.Values(values, probe, res.Af())
+
+	key := m.id + "/" + strconv.Itoa(probe.ID)
+	success := exporter.Success(key, res.Timestamp(), res.Min() > 0, m.failureThreshold)
+	ch <- prometheus.MustNewConstMetric(m.successDesc, prometheus.GaugeValue, success, labelValues...)
+
+	if m.upDesc != nil {
+		ch <- prometheus.MustNewConstMetric(m.upDesc, prometheus.GaugeValue, success, labelValues...)
 	}
 
 	if res.Min() > 0 {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 1, labelValues...)
-		ch <- prometheus.MustNewConstMetric(minLatencyDesc, prometheus.GaugeValue, res.Min(), labelValues...)
-		ch <- prometheus.MustNewConstMetric(maxLatencyDesc, prometheus.GaugeValue, res.Max(), labelValues...)
-		ch <- prometheus.MustNewConstMetric(avgLatencyDesc, prometheus.GaugeValue, res.Avg(), labelValues...)
-	} else {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.minLatencyDesc, prometheus.GaugeValue, res.Min(), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.maxLatencyDesc, prometheus.GaugeValue, res.Max(), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.avgLatencyDesc, prometheus.GaugeValue, res.Avg(), labelValues...)
+	}
+
+	if j, ok := jitter(replyRTTs(res)); ok {
+		ch <- prometheus.MustNewConstMetric(m.jitterDesc, prometheus.GaugeValue, j, labelValues...)
+	}
+
+	ch <- prometheus.MustNewConstMetric(m.sentDesc, prometheus.GaugeValue, float64(res.Sent()), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.rcvdDesc, prometheus.GaugeValue, float64(res.Rcvd()), labelValues...)
+	if m.packetRTT {
+		for _, p := range packetRTTs(res) {
+			ch <- prometheus.MustNewConstMetric(m.packetRttDesc, prometheus.GaugeValue, p.rtt, exporter.WithLabels(labelValues, strconv.Itoa(p.seq))...)
+		}
 	}
 
-	ch <- prometheus.MustNewConstMetric(sentDesc, prometheus.GaugeValue, float64(res.Sent()), labelValues...)
-	ch <- prometheus.MustNewConstMetric(rcvdDesc, prometheus.GaugeValue, float64(res.Rcvd()), labelValues...)
-	ch <- prometheus.MustNewConstMetric(dupDesc, prometheus.GaugeValue, float64(res.Dup()), labelValues...)
-	ch <- prometheus.MustNewConstMetric(ttlDesc, prometheus.GaugeValue, float64(res.Ttl()), labelValues...)
-	ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(res.Size()), labelValues...)
+	if res.Sent() > 0 {
+		ch <- prometheus.MustNewConstMetric(m.lossDesc, prometheus.GaugeValue, lossRatio(res.Sent(), res.Rcvd()), labelValues...)
+	}
+	ch <- prometheus.MustNewConstMetric(m.dupDesc, prometheus.GaugeValue, float64(res.Dup()), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.ttlDesc, prometheus.GaugeValue, float64(replyTTL(res)), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.sizeDesc, prometheus.GaugeValue, float64(res.Size()), labelValues...)
 }
 
 // Describe exports metric descriptions for Prometheus
 func (m *pingExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- successDesc
-	ch <- minLatencyDesc
-	ch <- maxLatencyDesc
-	ch <- avgLatencyDesc
-	ch <- sentDesc
-	ch <- rcvdDesc
-	ch <- dupDesc
-	ch <- ttlDesc
-	ch <- sizeDesc
+	ch <- m.successDesc
+	ch <- m.minLatencyDesc
+	ch <- m.maxLatencyDesc
+	ch <- m.avgLatencyDesc
+	ch <- m.sentDesc
+	ch <- m.rcvdDesc
+	ch <- m.dupDesc
+	ch <- m.ttlDesc
+	ch <- m.sizeDesc
+	ch <- m.jitterDesc
+	ch <- m.lossDesc
+
+	if m.packetRTT {
+		ch <- m.packetRttDesc
+	}
+
+	if m.upDesc != nil {
+		ch <- m.upDesc
+	}
+}
+
+// lossRatio returns the ratio of requests without response (duplicates are not counted in rcvd)
+func lossRatio(sent, rcvd int) float64 {
+	if rcvd >= sent {
+		return 0
+	}
+
+	return float64(sent-rcvd) / float64(sent)
 }
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLossRatio(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","dst_name":"192.0.2.1","sent":4,"rcvd":3,"min":10,"avg":12,"max":14}`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+	m.Add(testResult(t, `{"type":"ping","prb_id":2,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","dst_name":"192.0.2.1","sent":3,"rcvd":0,"min":-1,"avg":-1,"max":-1}`), &probe.Probe{ID: 2, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_ping_loss_ratio Ratio of icmp requests without response
+# TYPE atlas_ping_loss_ratio gauge
+atlas_ping_loss_ratio{asn="64496",country_code="DE",dst_addr="192.0.2.1",dst_name="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="ping",probe="1"} 0.25
+atlas_ping_loss_ratio{asn="64496",country_code="DE",dst_addr="192.0.2.1",dst_name="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="ping",probe="2"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_ping_loss_ratio")
+	assert.NoError(t, err)
+}
+
+func TestRttHistogram(t *testing.T) {
+	expected := `
+# HELP atlas_ping_rtt_hist Histogram of round trip times over all ICMP requests
+# TYPE atlas_ping_rtt_hist histogram
+atlas_ping_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="ping",le="10"} 1
+atlas_ping_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="ping",le="20"} 3
+atlas_ping_rtt_hist_bucket{ip_version="4",measurement="1",measurement_type="ping",le="+Inf"} 3
+atlas_ping_rtt_hist_sum{ip_version="4",measurement="1",measurement_type="ping"} 36
+atlas_ping_rtt_hist_count{ip_version="4",measurement="1",measurement_type="ping"} 3
+`
+
+	m := NewMeasurement("1", "4", &config.Config{HistogramBuckets: config.HistogramBuckets{Ping: config.RttHistogramBucket{Rtt: []float64{10, 20}}}})
+	m.Add(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","sent":3,"rcvd":2,"min":10,"avg":12,"max":14,"result":[{"rtt":10},{"x":"*"},{"rtt":14},{"rtt":14,"dup":1}]}`), &probe.Probe{ID: 1})
+	m.Add(testResult(t, `{"type":"ping","prb_id":2,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","sent":1,"rcvd":1,"min":12,"avg":12,"max":12,"result":[{"rtt":12}]}`), &probe.Probe{ID: 2})
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_ping_rtt_hist")
+	assert.NoError(t, err)
+}
+
+func TestDup(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","dst_name":"192.0.2.1","sent":3,"rcvd":3,"dup":2,"min":10,"avg":12,"max":14,"result":[{"rtt":10},{"rtt":10,"dup":1},{"rtt":12},{"rtt":14},{"rtt":14,"dup":1}]}`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_ping_dup Number of duplicate icmp repsponses
+# TYPE atlas_ping_dup gauge
+atlas_ping_dup{asn="64496",country_code="DE",dst_addr="192.0.2.1",dst_name="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="ping",probe="1"} 2
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_ping_dup")
+	assert.NoError(t, err)
+}
+
+func TestSizeLabel(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{Ping: config.PingConfig{SizeLabel: true}})
+	m.Add(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","dst_name":"192.0.2.1","size":1000,"sent":1,"rcvd":1,"min":10,"avg":10,"max":10}`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_ping_success Destination was reachable
+# TYPE atlas_ping_success gauge
+atlas_ping_success{asn="64496",country_code="DE",dst_addr="192.0.2.1",dst_name="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="ping",probe="1",size="1000"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_ping_success")
+	assert.NoError(t, err)
+}
+
+func TestSrcAddrLabel(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{SrcAddrLabel: true})
+	m.Add(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","dst_name":"192.0.2.1","src_addr":"10.0.0.2","sent":1,"rcvd":1,"min":10,"avg":10,"max":10}`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_ping_success Destination was reachable
+# TYPE atlas_ping_success gauge
+atlas_ping_success{asn="64496",country_code="DE",dst_addr="192.0.2.1",dst_name="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="ping",probe="1",src_addr="10.0.0.2"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_ping_success")
+	assert.NoError(t, err)
+}
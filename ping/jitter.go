@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"math"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+// replyRTTs returns the RTTs of the replies in the order they were sent (timeouts and duplicates are skipped)
+func replyRTTs(res *measurement.Result) []float64 {
+	rtts := make([]float64, 0, len(res.PingResults()))
+	for _, r := range res.PingResults() {
+		if r.Rtt() > 0 && r.Dup() == 0 {
+			rtts = append(rtts, r.Rtt())
+		}
+	}
+
+	return rtts
+}
+
+// jitter returns the mean absolute difference of consecutive RTTs (false if there are less than two replies)
+func jitter(rtts []float64) (float64, bool) {
+	if len(rtts) < 2 {
+		return 0, false
+	}
+
+	var sum float64
+	for i := 1; i < len(rtts); i++ {
+		sum += math.Abs(rtts[i] - rtts[i-1])
+	}
+
+	return sum / float64(len(rtts)-1), true
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResult(t *testing.T, js string) *measurement.Result {
+	res := &measurement.Result{}
+	if err := json.Unmarshal([]byte(js), res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestJitter(t *testing.T) {
+	res := testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","result":[{"rtt":10},{"x":"*"},{"rtt":14},{"rtt":14,"dup":1},{"rtt":12}]}`)
+
+	rtts := replyRTTs(res)
+	assert.Equal(t, []float64{10, 14, 12}, rtts)
+
+	j, ok := jitter(rtts)
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, j)
+
+	_, ok = jitter([]float64{10})
+	assert.False(t, ok)
+}
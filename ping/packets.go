@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+type packetRTT struct {
+	seq int
+	rtt float64
+}
+
+// packetRTTs returns the RTTs of the replies by sequence (index of the request in the result, starting at 0).
+// Timeouts and duplicates are skipped.
+func packetRTTs(res *measurement.Result) []packetRTT {
+	rtts := make([]packetRTT, 0, len(res.PingResults()))
+	seq := -1
+	for _, r := range res.PingResults() {
+		if r.Dup() != 0 {
+			continue
+		}
+
+		seq++
+		if r.Rtt() > 0 {
+			rtts = append(rtts, packetRTT{seq: seq, rtt: r.Rtt()})
+		}
+	}
+
+	return rtts
+}
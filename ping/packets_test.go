@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPacketRTTs(t *testing.T) {
+	res := testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","result":[{"rtt":10},{"x":"*"},{"rtt":14},{"rtt":14,"dup":1},{"rtt":12}]}`)
+
+	expected := []packetRTT{
+		{seq: 0, rtt: 10},
+		{seq: 2, rtt: 14},
+		{seq: 3, rtt: 12},
+	}
+	assert.Equal(t, expected, packetRTTs(res))
+}
@@ -25,18 +25,17 @@ func newRttHistogram(id, ipVersion string, buckets []float64) exporter.Histogram
 			Buckets:   buckets,
 			Help:      "Histogram of round trip times over all ICMP requests",
 			ConstLabels: prometheus.Labels{
-				"measurement": id,
-				"ip_version":  ipVersion,
+				"measurement":      id,
+				"ip_version":       ipVersion,
+				"measurement_type": sub,
 			},
 		}),
 	}
 }
 
 func (h *rttHistogram) ProcessResult(r *measurement.Result) {
-	for _, p := range r.PingResults() {
-		if p.Rtt() > 0 {
-			h.rtt.Observe(p.Rtt())
-		}
+	for _, rtt := range replyRTTs(r) {
+		h.rtt.Observe(rtt)
 	}
 }
 
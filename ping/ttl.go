@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+// replyTTL returns the TTL of the replies. Older firmwares only report the TTL in the first reply.
+func replyTTL(res *measurement.Result) int {
+	if res.Ttl() > 0 {
+		return res.Ttl()
+	}
+
+	for _, r := range res.PingResults() {
+		if r.Ttl() > 0 {
+			return r.Ttl()
+		}
+	}
+
+	return 0
+}
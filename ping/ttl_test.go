@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package ping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplyTTL(t *testing.T) {
+	assert.Equal(t, 54, replyTTL(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","ttl":54,"result":[{"rtt":10,"ttl":53}]}`)))
+	assert.Equal(t, 53, replyTTL(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","result":[{"x":"*"},{"rtt":10,"ttl":53}]}`)))
+	assert.Equal(t, 0, replyTTL(testResult(t, `{"type":"ping","prb_id":1,"msm_id":1,"af":4,"dst_addr":"192.0.2.1","result":[{"x":"*"}]}`)))
+}
@@ -7,13 +7,18 @@ import (
 	"strconv"
 )
 
-const ipv6 int = 6
+const (
+	ipv6                       int = 6
+	defaultCoordinatePrecision int = 4
+)
 
 // Probe holds information about a single Atlas probe
 type Probe struct {
 	ID          int    `json:"id"`
 	Asn4        int    `json:"asn_v4"`
 	Asn6        int    `json:"asn_v6"`
+	Prefix4     string `json:"prefix_v4"`
+	Prefix6     string `json:"prefix_v6"`
 	CountryCode string `json:"country_code"`
 	Geometry    struct {
 		Coordinates []float64 `json:"coordinates"`
@@ -41,20 +46,39 @@ func (p *Probe) ASNForIPVersion(v int) int {
 	return p.Asn4
 }
 
+// PrefixForIPVersion return the announced prefix the probe is located in for the given IP Version
+func (p *Probe) PrefixForIPVersion(v int) string {
+	if v == ipv6 {
+		return p.Prefix6
+	}
+
+	return p.Prefix4
+}
+
 // Longitude of the geo location of the probe
 func (p *Probe) Longitude() string {
+	return p.LongitudeWithPrecision(defaultCoordinatePrecision)
+}
+
+// Latitude of the geo location of the probe
+func (p *Probe) Latitude() string {
+	return p.LatitudeWithPrecision(defaultCoordinatePrecision)
+}
+
+// LongitudeWithPrecision returns the longitude of the probe rounded to the given number of decimals
+func (p *Probe) LongitudeWithPrecision(precision int) string {
 	if len(p.Geometry.Coordinates) == 0 {
 		return ""
 	}
 
-	return strconv.FormatFloat(p.Geometry.Coordinates[0], 'f', 4, 64)
+	return strconv.FormatFloat(p.Geometry.Coordinates[0], 'f', precision, 64)
 }
 
-// Latitude of the geo location of the probe
-func (p *Probe) Latitude() string {
-	if len(p.Geometry.Coordinates) == 0 {
+// LatitudeWithPrecision returns the latitude of the probe rounded to the given number of decimals
+func (p *Probe) LatitudeWithPrecision(precision int) string {
+	if len(p.Geometry.Coordinates) < 2 {
 		return ""
 	}
 
-	return strconv.FormatFloat(p.Geometry.Coordinates[1], 'f', 4, 64)
+	return strconv.FormatFloat(p.Geometry.Coordinates[1], 'f', precision, 64)
 }
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var probeInfoDesc = prometheus.NewDesc("atlas_probe_info", "Information about probes delivering results for the measurements", []string{"probe", "asn_v4", "asn_v6", "country_code", "lat", "long"}, nil)
+
+type probeInfoCollector struct {
+	measurements        []*exporter.Measurement
+	coordinatePrecision int
+}
+
+func newProbeInfoCollector(measurements []*exporter.Measurement, coordinatePrecision int) *probeInfoCollector {
+	return &probeInfoCollector{
+		measurements:        measurements,
+		coordinatePrecision: coordinatePrecision,
+	}
+}
+
+// Collect implements Prometheus Collector interface
+func (c *probeInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, p := range c.probes() {
+		ch <- prometheus.MustNewConstMetric(probeInfoDesc, prometheus.GaugeValue, 1,
+			strconv.Itoa(p.ID),
+			strconv.Itoa(p.Asn4),
+			strconv.Itoa(p.Asn6),
+			p.CountryCode,
+			p.LatitudeWithPrecision(c.coordinatePrecision),
+			p.LongitudeWithPrecision(c.coordinatePrecision))
+	}
+}
+
+func (c *probeInfoCollector) probes() []*probe.Probe {
+	seen := make(map[int]bool)
+	probes := make([]*probe.Probe, 0)
+
+	for _, m := range c.measurements {
+		for _, p := range m.Probes() {
+			if seen[p.ID] {
+				continue
+			}
+
+			seen[p.ID] = true
+			probes = append(probes, p)
+		}
+	}
+
+	return probes
+}
+
+// Describe implements Prometheus Collector interface
+func (c *probeInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- probeInfoDesc
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/ping"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const pingResult = `{
+	"fw": 5080,
+	"lts": 12,
+	"dst_name": "8.8.8.8",
+	"dst_addr": "8.8.8.8",
+	"src_addr": "192.168.1.10",
+	"proto": "ICMP",
+	"af": 4,
+	"size": 48,
+	"result": [{"rtt": 10.1}, {"rtt": 10.3}, {"rtt": 10.2}],
+	"msm_id": %MSM%,
+	"prb_id": %PRB%,
+	"timestamp": 1700000000,
+	"msm_name": "Ping",
+	"from": "198.51.100.10",
+	"type": "ping",
+	"group_id": %MSM%,
+	"step": 240,
+	"stored_timestamp": 1700000003,
+	"avg": 10.2,
+	"min": 10.1,
+	"max": 10.3,
+	"sent": 3,
+	"rcvd": 3,
+	"dup": 0,
+	"ttl": 56
+}`
+
+func testPingResult(t *testing.T, msm, prb string) *measurement.Result {
+	res := &measurement.Result{}
+	err := json.Unmarshal([]byte(strings.NewReplacer("%MSM%", msm, "%PRB%", prb).Replace(pingResult)), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestProbeInfoCollector(t *testing.T) {
+	p1 := &probe.Probe{ID: 1, Asn4: 64496, Asn6: 64497, CountryCode: "DE"}
+	p1.Geometry.Coordinates = []float64{8.68417, 50.11552}
+	p2 := &probe.Probe{ID: 2, Asn4: 64498, CountryCode: "NL"}
+
+	cfg := &config.Config{}
+	m1 := ping.NewMeasurement("1001", "4", cfg)
+	m1.Add(testPingResult(t, "1001", "1"), p1)
+	m2 := ping.NewMeasurement("1002", "4", cfg)
+	m2.Add(testPingResult(t, "1002", "1"), p1)
+	m2.Add(testPingResult(t, "1002", "2"), p2)
+
+	expected := `
+# HELP atlas_probe_info Information about probes delivering results for the measurements
+# TYPE atlas_probe_info gauge
+atlas_probe_info{asn_v4="64496",asn_v6="64497",country_code="DE",lat="50.12",long="8.68",probe="1"} 1
+atlas_probe_info{asn_v4="64498",asn_v6="0",country_code="NL",lat="",long="",probe="2"} 1
+`
+
+	c := newProbeInfoCollector([]*exporter.Measurement{m1, m2}, 2)
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
+)
+
+const pushGroupingLabel = "atlas_measurement"
+
+func startPushing(ctx context.Context) {
+	log.Infof("Pushing metrics to %s every %v (job: %s)", *pushURL, *pushInterval, *pushJob)
+
+	go func() {
+		t := time.NewTicker(*pushInterval)
+		defer t.Stop()
+
+		for {
+			pushMeasurements(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func pushMeasurements(ctx context.Context) {
+	for _, id := range cfg.MeasurementIDs() {
+		err := pushMeasurement(ctx, id)
+		if err != nil {
+			log.Errorf("could not push metrics for measurement %s: %v", id, err)
+		}
+	}
+}
+
+func pushMeasurement(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	measurements, err := strategy.MeasurementResults(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+
+	if len(measurements) == 0 {
+		return nil
+	}
+
+	// the grouping label must not clash with the measurement label of the pushed metrics (rejected by the client)
+	return push.New(*pushURL, *pushJob).
+		Grouping(pushGroupingLabel, id).
+		Collector(newCollector(measurements)).
+		PushContext(ctx)
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/ping"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubStrategy struct {
+	results map[string][]*exporter.Measurement
+}
+
+func (s *stubStrategy) MeasurementResults(ctx context.Context, ids []string) ([]*exporter.Measurement, error) {
+	var res []*exporter.Measurement
+	for _, id := range ids {
+		res = append(res, s.results[id]...)
+	}
+
+	return res, nil
+}
+
+func TestPushMeasurements(t *testing.T) {
+	var mutex sync.Mutex
+	bodies := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPut, r.Method)
+
+		mutex.Lock()
+		bodies[r.URL.Path] = string(b)
+		mutex.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := ping.NewMeasurement("1001", "4", &config.Config{})
+	m.Add(testPingResult(t, "1001", "1"), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	oldCfg, oldStrategy, oldURL := cfg, strategy, *pushURL
+	defer func() {
+		cfg, strategy, *pushURL = oldCfg, oldStrategy, oldURL
+	}()
+	cfg = &config.Config{Measurements: []config.Measurement{{ID: "1001"}, {ID: "1002"}}}
+	strategy = &stubStrategy{results: map[string][]*exporter.Measurement{"1001": {m}}}
+	*pushURL = srv.URL
+
+	pushMeasurements(context.Background())
+
+	assert.Len(t, bodies, 1, "measurements without results must not be pushed")
+	body, found := bodies["/metrics/job/atlas_exporter/atlas_measurement/1001"]
+	if assert.True(t, found, "metrics must be pushed grouped by measurement") {
+		assert.Contains(t, body, "atlas_ping_avg_latency")
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import rsslcert "github.com/DNS-OARC/ripeatlas/measurement/sslcert"
+
+// alertDescriptions contains names of alerts defined after RFC 5246 (not known to ripeatlas)
+var alertDescriptions = map[int]string{
+	alertInappropriateFallback: "inappropriate_fallback",
+	109:                        "missing_extension",
+	111:                        "certificate_unobtainable",
+	112:                        "unrecognized_name",
+	113:                        "bad_certificate_status_response",
+	114:                        "bad_certificate_hash_value",
+	115:                        "unknown_psk_identity",
+	116:                        "certificate_required",
+	120:                        "no_application_protocol",
+}
+
+// alertNames returns the names of level and description of the alert ("unknown" for values not defined by the TLS RFCs)
+func alertNames(alert *rsslcert.Alert) (string, string) {
+	level, err := alert.LevelString()
+	if err != nil {
+		level = "unknown"
+	}
+
+	desc, err := alert.DescriptionString()
+	if err != nil {
+		var found bool
+		if desc, found = alertDescriptions[alert.Description()]; !found {
+			desc = "unknown"
+		}
+	}
+
+	return level, desc
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"encoding/json"
+	"testing"
+
+	rsslcert "github.com/DNS-OARC/ripeatlas/measurement/sslcert"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		alert       string
+		level       string
+		description string
+	}{
+		{name: "handshake failure", alert: `{"level":2,"description":40}`, level: "fatal", description: "handshake_failure"},
+		{name: "unknown CA", alert: `{"level":2,"description":48}`, level: "fatal", description: "unknown_ca"},
+		{name: "inappropriate fallback", alert: `{"level":2,"description":86}`, level: "fatal", description: "inappropriate_fallback"},
+		{name: "unrecognized name", alert: `{"level":1,"description":112}`, level: "warning", description: "unrecognized_name"},
+		{name: "unknown", alert: `{"level":3,"description":255}`, level: "unknown", description: "unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alert := &rsslcert.Alert{}
+			if err := json.Unmarshal([]byte(test.alert), alert); err != nil {
+				t.Fatal(err)
+			}
+
+			level, description := alertNames(alert)
+			assert.Equal(t, test.level, level)
+			assert.Equal(t, test.description, description)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"sync"
+)
+
+const certCacheSize = 1024
+
+// parsedCerts caches parsed certificates between scrapes (most probes of a measurement are served the same certificates)
+var parsedCerts = newCertCache(certCacheSize)
+
+// certCache is a LRU cache of parsed certificates keyed by SHA-256 fingerprint
+type certCache struct {
+	mutex sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+}
+
+type certCacheEntry struct {
+	fingerprint string
+	cert        *x509.Certificate
+}
+
+func newCertCache(size int) *certCache {
+	return &certCache{
+		size:  size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (c *certCache) get(fingerprint string) (*x509.Certificate, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, found := c.items[fingerprint]
+	if !found {
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return e.Value.(*certCacheEntry).cert, true
+}
+
+func (c *certCache) add(fingerprint string, cert *x509.Certificate) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, found := c.items[fingerprint]; found {
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[fingerprint] = c.order.PushFront(&certCacheEntry{fingerprint: fingerprint, cert: cert})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*certCacheEntry).fingerprint)
+	}
+}
+
+func (c *certCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+// parseCertificate parses a DER encoded certificate. Parsed certificates are cached by fingerprint and must not be modified.
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	fp := fmt.Sprintf("%x", sha256.Sum256(der))
+	if cert, found := parsedCerts.get(fp); found {
+		return cert, nil
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedCerts.add(fp, cert)
+	return cert, nil
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertCache(t *testing.T) {
+	c := newCertCache(2)
+	a, b, d := &x509.Certificate{}, &x509.Certificate{}, &x509.Certificate{}
+
+	c.add("a", a)
+	c.add("b", b)
+
+	cert, found := c.get("a")
+	assert.True(t, found)
+	assert.Same(t, a, cert)
+
+	c.add("d", d)
+	assert.Equal(t, 2, c.len())
+
+	_, found = c.get("b")
+	assert.False(t, found, "least recently used entry should be evicted")
+
+	cert, found = c.get("d")
+	assert.True(t, found)
+	assert.Same(t, d, cert)
+}
+
+func TestParseCertificate(t *testing.T) {
+	der := derFromCert(testCertificate(t))
+
+	first, err := parseCertificate(der)
+	assert.NoError(t, err)
+
+	second, err := parseCertificate(der)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+
+	_, err = parseCertificate([]byte("invalid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	distinctCertsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "distinct_certs"),
+		"Number of distinct leaf certificates served to the probes for a target",
+		[]string{"measurement", "target"},
+		constLabels,
+	)
+	certProbesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "cert_probes"),
+		"Number of probes the leaf certificate was served to for a target",
+		[]string{"measurement", "target", "fingerprint"},
+		constLabels,
+	)
+)
+
+type certDivergence struct {
+	id string
+}
+
+// Aggregate exports the number of distinct leaf certificates per target and the number of probes per certificate
+func (a *certDivergence) Aggregate(results []*measurement.Result, ch chan<- prometheus.Metric) {
+	probes := make(map[string]map[string]int)
+	for _, res := range results {
+		fp := fingerprintFromResult(res)
+		if len(fp) == 0 {
+			continue
+		}
+
+		target := targetFromResult(res)
+		if probes[target] == nil {
+			probes[target] = make(map[string]int)
+		}
+		probes[target][fp]++
+	}
+
+	for target, counts := range probes {
+		ch <- prometheus.MustNewConstMetric(distinctCertsDesc, prometheus.GaugeValue, float64(len(counts)), a.id, target)
+
+		for fp, count := range counts {
+			ch <- prometheus.MustNewConstMetric(certProbesDesc, prometheus.GaugeValue, float64(count), a.id, target, fp)
+		}
+	}
+}
+
+// Describe exports metric descriptions for Prometheus
+func (a *certDivergence) Describe(ch chan<- *prometheus.Desc) {
+	ch <- distinctCertsDesc
+	ch <- certProbesDesc
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertDivergence(t *testing.T) {
+	common, other := testCertificate(t), testCertificate(t)
+	results := []*measurement.Result{
+		testProbeResult(t, 1, []string{common}),
+		testProbeResult(t, 2, []string{common}),
+		testProbeResult(t, 3, []string{other}),
+		testProbeResult(t, 4, nil),
+	}
+
+	series := []string{
+		fmt.Sprintf(`atlas_sslcert_cert_probes{fingerprint="%s",measurement="1",measurement_type="sslcert",target="192.0.2.1"} 2`, fingerprintFromResult(results[0])),
+		fmt.Sprintf(`atlas_sslcert_cert_probes{fingerprint="%s",measurement="1",measurement_type="sslcert",target="192.0.2.1"} 1`, fingerprintFromResult(results[2])),
+	}
+	sort.Strings(series)
+
+	expected := `
+# HELP atlas_sslcert_cert_probes Number of probes the leaf certificate was served to for a target
+# TYPE atlas_sslcert_cert_probes gauge
+` + strings.Join(series, "\n") + `
+# HELP atlas_sslcert_distinct_certs Number of distinct leaf certificates served to the probes for a target
+# TYPE atlas_sslcert_distinct_certs gauge
+atlas_sslcert_distinct_certs{measurement="1",measurement_type="sslcert",target="192.0.2.1"} 2
+`
+
+	m := NewMeasurement("1", &config.Config{})
+	for _, res := range results {
+		m.Add(res, &probe.Probe{ID: res.PrbId()})
+	}
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_cert_probes", "atlas_sslcert_distinct_certs")
+	assert.NoError(t, err)
+}
+
+func testProbeResult(t *testing.T, probeID int, certs []string) *measurement.Result {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   probeID,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"rt":       10,
+		"cert":     certs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
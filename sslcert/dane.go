@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"strings"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	mdns "github.com/miekg/dns"
+)
+
+const (
+	tlsaUsagePKIXTA = 0
+	tlsaUsageDANETA = 2
+)
+
+// verifyTLSA checks if the served certificate chain matches the expected TLSA record.
+// Usages PKIX-EE (1) and DANE-EE (3) are matched against the leaf, PKIX-TA (0) and DANE-TA (2)
+// against any certificate of the chain. PKIX path validation is not performed.
+func verifyTLSA(tlsa *config.TLSA, certs []string) bool {
+	if len(certs) == 0 {
+		return false
+	}
+
+	if tlsa.Usage != tlsaUsagePKIXTA && tlsa.Usage != tlsaUsageDANETA {
+		certs = certs[:1]
+	}
+
+	chain := parseCertificates(certs)
+
+	expected := strings.ToLower(tlsa.Data)
+	for _, cert := range chain {
+		d, err := mdns.CertificateToDANE(tlsa.Selector, tlsa.MatchingType, cert)
+		if err != nil {
+			return false
+		}
+
+		if d == expected {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTLSA(t *testing.T) {
+	caPEM, leafPEM := testChain(t, time.Now())
+	chain := parseCertificates([]string{leafPEM, caPEM})
+	leaf, ca := chain[0], chain[1]
+
+	sha256Hex := func(b []byte) string {
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:])
+	}
+	sha512Hex := func(b []byte) string {
+		h := sha512.Sum512(b)
+		return hex.EncodeToString(h[:])
+	}
+
+	tests := []struct {
+		name     string
+		tlsa     config.TLSA
+		certs    []string
+		expected bool
+	}{
+		{
+			name:     "DANE-EE full certificate SHA-256",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 1, Data: sha256Hex(leaf.Raw)},
+			certs:    []string{leafPEM, caPEM},
+			expected: true,
+		},
+		{
+			name:     "DANE-EE SPKI SHA-256",
+			tlsa:     config.TLSA{Usage: 3, Selector: 1, MatchingType: 1, Data: sha256Hex(leaf.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM, caPEM},
+			expected: true,
+		},
+		{
+			name:     "DANE-EE SPKI SHA-512",
+			tlsa:     config.TLSA{Usage: 3, Selector: 1, MatchingType: 2, Data: sha512Hex(leaf.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM},
+			expected: true,
+		},
+		{
+			name:     "DANE-EE full certificate SHA-512",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 2, Data: sha512Hex(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: true,
+		},
+		{
+			name:     "DANE-EE full certificate exact match",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 0, Data: hex.EncodeToString(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: true,
+		},
+		{
+			name:     "data in upper case",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 1, Data: strings.ToUpper(sha256Hex(leaf.Raw))},
+			certs:    []string{leafPEM},
+			expected: true,
+		},
+		{
+			name:     "SPKI data with full certificate selector",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 1, Data: sha256Hex(leaf.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "full certificate data with SPKI selector",
+			tlsa:     config.TLSA{Usage: 3, Selector: 1, MatchingType: 1, Data: sha256Hex(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "SHA-256 data with SHA-512 matching type",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 2, Data: sha256Hex(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "DANE-TA matching the issuer",
+			tlsa:     config.TLSA{Usage: 2, Selector: 1, MatchingType: 1, Data: sha256Hex(ca.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM, caPEM},
+			expected: true,
+		},
+		{
+			name:     "PKIX-TA matching the issuer",
+			tlsa:     config.TLSA{Usage: 0, Selector: 0, MatchingType: 1, Data: sha256Hex(ca.Raw)},
+			certs:    []string{leafPEM, caPEM},
+			expected: true,
+		},
+		{
+			name:     "DANE-TA with issuer not served",
+			tlsa:     config.TLSA{Usage: 2, Selector: 1, MatchingType: 1, Data: sha256Hex(ca.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "DANE-EE matching the issuer only",
+			tlsa:     config.TLSA{Usage: 3, Selector: 1, MatchingType: 1, Data: sha256Hex(ca.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM, caPEM},
+			expected: false,
+		},
+		{
+			name:     "PKIX-EE matching the leaf",
+			tlsa:     config.TLSA{Usage: 1, Selector: 1, MatchingType: 1, Data: sha256Hex(leaf.RawSubjectPublicKeyInfo)},
+			certs:    []string{leafPEM, caPEM},
+			expected: true,
+		},
+		{
+			name:     "invalid selector",
+			tlsa:     config.TLSA{Usage: 3, Selector: 2, MatchingType: 1, Data: sha256Hex(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "invalid matching type",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 3, Data: sha256Hex(leaf.Raw)},
+			certs:    []string{leafPEM},
+			expected: false,
+		},
+		{
+			name:     "no certificates",
+			tlsa:     config.TLSA{Usage: 3, Selector: 0, MatchingType: 1, Data: sha256Hex(leaf.Raw)},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			assert.Equal(te, test.expected, verifyTLSA(&test.tlsa, test.certs))
+		})
+	}
+}
@@ -3,143 +3,625 @@
 package sslcert
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// alertInappropriateFallback is sent by servers detecting a protocol downgrade by the client (RFC 7507)
+	alertInappropriateFallback = 86
+
+	// maxSANs is the maximum number of subject alternative names in the cert_san label
+	maxSANs = 5
+)
+
 var (
-	labels               []string
+	constLabels = prometheus.Labels{"measurement_type": sub}
+
+	labels = []string{"measurement", "probe", "dst_addr", "asn", "ip_version", "country_code", "lat", "long", "cert_fingerprint", "cert_issuer"}
+
+	// certLabels describe the served certificate, they are not added to the counter of certificate changes
+	// (which would otherwise start a new series on every change)
+	certLabels = map[string]bool{
+		"cert_fingerprint":      true,
+		"cert_issuer":           true,
+		"cert_subject":          true,
+		"cert_san":              true,
+		"cert_fingerprint_sha1": true,
+		"cert_fingerprint_md5":  true,
+	}
+)
+
+type sslCertExporter struct {
+	id                  string
+	tlsa                *config.TLSA
+	verifier            *chainVerifier
+	pinnedFingerprints  map[string]bool
+	tlsVersionLabel     bool
+	coordinatePrecision int
+	failureThreshold    int
+	unknownIssuer       string
+	issuerDN            bool
+	fingerprintChange   bool
+	subjectLabels       bool
+	dstNameLabel        bool
+	dstPortLabel        bool
+	legacyVersion       bool
+	legacyFingerprints  bool
+	alertLabels         bool
+	srcAddrLabel        bool
+	labelNames          []string
+	labelSet            *exporter.LabelSet
+	changesLabelSet     *exporter.LabelSet
+
 	rttDesc              *prometheus.Desc
+	connectTimeDesc      *prometheus.Desc
 	sslVerDesc           *prometheus.Desc
+	versionInfoDesc      *prometheus.Desc
 	successDesc          *prometheus.Desc
+	upDesc               *prometheus.Desc
+	successVersionDesc   *prometheus.Desc
 	alertLevelDesc       *prometheus.Desc
 	alertDescriptionDesc *prometheus.Desc
-)
+	alertDesc            *prometheus.Desc
+	daneValidDesc        *prometheus.Desc
+	issuerParsedDesc     *prometheus.Desc
+	fpChangedDesc        *prometheus.Desc
+	certChangesDesc      *prometheus.Desc
+	downgradeDesc        *prometheus.Desc
+	notAfterDesc         *prometheus.Desc
+	notBeforeDesc        *prometheus.Desc
+	chainInfoDesc        *prometheus.Desc
+	chainNotAfterDesc    *prometheus.Desc
+	keyBitsDesc          *prometheus.Desc
+	signatureAlgDesc     *prometheus.Desc
+	chainValidDesc       *prometheus.Desc
+	fpMatchDesc          *prometheus.Desc
+	certInfoDesc         *prometheus.Desc
+	selfSignedDesc       *prometheus.Desc
+	wildcardDesc         *prometheus.Desc
+	sanCountDesc         *prometheus.Desc
+}
 
-func init() {
-	labels = []string{"measurement", "probe", "dst_addr", "asn", "ip_version", "country_code", "lat", "long", "cert_fingerprint", "cert_issuer"}
+// newSSLCertExporter returns a new exporter (the labels of the metrics depend on the config)
+func newSSLCertExporter(id string, cfg *config.Config) *sslCertExporter {
+	e := &sslCertExporter{
+		id:                  id,
+		tlsVersionLabel:     cfg.SSLCert.TLSVersionLabel,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		failureThreshold:    cfg.FailureThreshold,
+		unknownIssuer:       cfg.SSLCert.UnknownIssuerValue(),
+		issuerDN:            cfg.SSLCert.IssuerDN,
+		fingerprintChange:   cfg.SSLCert.FingerprintChange,
+		subjectLabels:       cfg.SSLCert.SubjectLabels,
+		dstNameLabel:        cfg.SSLCert.DstNameLabel,
+		dstPortLabel:        cfg.SSLCert.DstPortLabel,
+		legacyVersion:       cfg.SSLCert.LegacyVersionMetric,
+		legacyFingerprints:  cfg.SSLCert.LegacyFingerprints,
+		alertLabels:         cfg.SSLCert.AlertLabels,
+		srcAddrLabel:        cfg.SrcAddrLabel,
+	}
 
-	successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", labels, nil)
-	sslVerDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "version"), "SSL/TLS version used for the request", labels, nil)
-	rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", labels, nil)
-	alertLevelDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "alert_level"), "Status of the SSL/TLS certificate (0 = valid)", labels, nil)
-	alertDescriptionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "alert_description"), "Description for the alert level (see RIPE Atlas documentation)", labels, nil)
+	names := exporter.WithLabels(labels)
+	if e.subjectLabels {
+		names = append(names, "cert_subject", "cert_san")
+	}
+	if e.dstNameLabel {
+		names = append(names, "dst_name")
+	}
+	if e.dstPortLabel {
+		names = append(names, "dst_port")
+	}
+	if e.legacyFingerprints {
+		names = append(names, "cert_fingerprint_sha1", "cert_fingerprint_md5")
+	}
+	if e.srcAddrLabel {
+		names = append(names, "src_addr")
+	}
+	e.labelNames = names
+	e.labelSet = exporter.NewLabelSet(names, cfg)
+	e.changesLabelSet = exporter.NewLabelSet(withoutCertLabels(names, names), cfg)
+
+	l := e.labelSet.Names()
+	e.successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", l, constLabels)
+	if cfg.UpMetric {
+		e.upDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "up"), "Destination was reachable (same as success)", l, constLabels)
+	}
+	e.successVersionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", exporter.WithLabels(l, "tls_version"), constLabels)
+	e.sslVerDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "version"), "SSL/TLS version used for the request", l, constLabels)
+	e.versionInfoDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "version_info"), "SSL/TLS version used for the request", exporter.WithLabels(l, "tls_version"), constLabels)
+	e.rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", l, constLabels)
+	e.connectTimeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "connect_time"), "Time to establish the TCP connection in ms (ttc)", l, constLabels)
+	e.alertLevelDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "alert_level"), "Status of the SSL/TLS certificate (0 = valid)", l, constLabels)
+	e.alertDescriptionDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "alert_description"), "Description for the alert level (see RIPE Atlas documentation)", l, constLabels)
+	e.alertDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "alert"), "Alert sent by the server (only exported for results containing an alert)", exporter.WithLabels(l, "alert_level", "alert_description"), constLabels)
+	e.issuerParsedDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "cert_issuer_parsed"), "Issuer could be extracted from the certificate (0 = fallback value used)", l, constLabels)
+	e.fpChangedDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "cert_fingerprint_changed"), "Leaf certificate fingerprint differs from the one of the previous result of the probe", l, constLabels)
+	e.certChangesDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "cert_changes_total"), "Number of leaf certificate fingerprint changes observed for the probe", e.changesLabelSet.Names(), constLabels)
+	e.downgradeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "tls_downgrade_detected"), "Server aborted the handshake with an inappropriate_fallback alert (only exported for results containing an alert)", l, constLabels)
+	e.notAfterDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "not_after_timestamp_seconds"), "Expiry of the leaf certificate served to the probe as Unix timestamp", l, constLabels)
+	e.notBeforeDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "not_before_timestamp_seconds"), "Start of the validity of the leaf certificate served to the probe as Unix timestamp", l, constLabels)
+	e.keyBitsDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "key_bits"), "Size of the public key of the leaf certificate in bits", exporter.WithLabels(l, "key_algorithm"), constLabels)
+	e.signatureAlgDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "signature_algorithm_info"), "Signature algorithm of the leaf certificate", exporter.WithLabels(l, "signature_algorithm"), constLabels)
+	e.certInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "cert_info"),
+		"Serial number and key identifiers (hex) of the leaf certificate",
+		exporter.WithLabels(l, "serial", "authority_key_id", "subject_key_id"),
+		constLabels,
+	)
+	e.selfSignedDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "self_signed"), "Leaf certificate is self-signed (issuer equals subject and the signature can be verified with its own key)", l, constLabels)
+	e.wildcardDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "cert_wildcard"), "Leaf certificate contains a wildcard name", l, constLabels)
+	e.sanCountDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "cert_san_count"), "Number of subject alternative names (DNS names and IP addresses) of the leaf certificate", l, constLabels)
+	e.chainInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "chain_cert_info"),
+		"Certificate of the chain served to the probe (chain_index = position in the chain, 0 = leaf)",
+		exporter.WithLabels(l, "chain_index", "fingerprint", "issuer"),
+		constLabels,
+	)
+	e.chainNotAfterDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(ns, sub, "chain_cert_not_after_timestamp_seconds"),
+		"Expiry of the certificate of the chain served to the probe as Unix timestamp (chain_index = position in the chain, 0 = leaf)",
+		exporter.WithLabels(l, "chain_index"),
+		constLabels,
+	)
+	e.chainValidDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "chain_valid"), "Served certificate chain could be verified against the root store (reason = cause of failure)", exporter.WithLabels(l, "reason"), constLabels)
+	e.fpMatchDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "fingerprint_match"), "Fingerprint of the served leaf certificate is one of the configured fingerprints (1 = match)", l, constLabels)
+	e.daneValidDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "dane_valid"), "Served certificate matches the configured TLSA record (1 = match)", l, constLabels)
+
+	return e
 }
 
-type sslCertExporter struct {
-	id string
+// normalizeFingerprint returns the fingerprint in the format of the cert_fingerprint label (lower case hex without colons)
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(fp, ":", ""))
 }
 
 func fingerprintFromResult(res *measurement.Result) string {
-	certs := res.Cert()
-	if len(certs) == 0 {
+	der := leafDER(res)
+	if der == nil {
 		return ""
 	}
 
-	if block, _ := pem.Decode([]byte(certs[0])); block != nil {
-		sum := sha256.Sum256(block.Bytes)
-		return fmt.Sprintf("%x", sum)
+	sum := sha256.Sum256(der)
+	return fmt.Sprintf("%x", sum)
+}
+
+// legacyFingerprintsFromResult returns the SHA-1 and MD5 fingerprints of the leaf certificate
+func legacyFingerprintsFromResult(res *measurement.Result) (string, string) {
+	der := leafDER(res)
+	if der == nil {
+		return "", ""
 	}
 
-	if der, err := base64.StdEncoding.DecodeString(certs[0]); err == nil {
-		sum := sha256.Sum256(der)
-		return fmt.Sprintf("%x", sum)
+	return fmt.Sprintf("%x", sha1.Sum(der)), fmt.Sprintf("%x", md5.Sum(der))
+}
+
+func leafDER(res *measurement.Result) []byte {
+	certs := res.Cert()
+	if len(certs) == 0 {
+		return nil
 	}
 
-	return ""
+	return derFromCert(certs[0])
 }
 
-func issuerOrgFromResult(res *measurement.Result) string {
+// withoutCertLabels returns the values of the labels not describing the served certificate
+func withoutCertLabels(names, values []string) []string {
+	res := make([]string, 0, len(values))
+	for i, name := range names {
+		if !certLabels[name] {
+			res = append(res, values[i])
+		}
+	}
+
+	return res
+}
+
+// issuerOrgFromResult returns the issuer of the certificate and if it could be extracted
+func issuerOrgFromResult(res *measurement.Result, fullDN bool) (string, bool) {
 	certs := res.Cert()
 	if len(certs) == 0 {
-		return "unknown"
+		return "", false
 	}
 
 	for _, raw := range certs {
-		var der []byte
-
-		if block, _ := pem.Decode([]byte(raw)); block != nil {
-			der = block.Bytes
-		} else {
-			// base64 DER
-			b, err := base64.StdEncoding.DecodeString(raw)
-			if err != nil {
-				continue
-			}
-			der = b
+		der := derFromCert(raw)
+		if der == nil {
+			continue
 		}
 
-		cert, err := x509.ParseCertificate(der)
+		cert, err := parseCertificate(der)
 		if err != nil {
 			continue
 		}
 
-		if len(cert.Issuer.Organization) > 0 && cert.Issuer.Organization[0] != "" {
-			return cert.Issuer.Organization[0]
+		return issuerName(cert, fullDN)
+	}
+
+	return "", false
+}
+
+// issuerName returns the organization (or common name) of the issuer of the certificate and if it could be extracted
+// (the full distinguished name in RFC 2253 format if fullDN is set)
+func issuerName(cert *x509.Certificate, fullDN bool) (string, bool) {
+	if fullDN {
+		dn := cert.Issuer.String()
+		return dn, dn != ""
+	}
+
+	if len(cert.Issuer.Organization) > 0 && cert.Issuer.Organization[0] != "" {
+		return cert.Issuer.Organization[0], true
+	}
+
+	// fall back to the common name if the organization is missing
+	if cn := cert.Issuer.CommonName; cn != "" {
+		return cn, true
+	}
+
+	return "", false
+}
+
+// leafCertificate returns the first certificate of the chain served to the probe (nil if missing or invalid)
+func leafCertificate(res *measurement.Result) *x509.Certificate {
+	certs := res.Cert()
+	if len(certs) == 0 {
+		return nil
+	}
+
+	der := derFromCert(certs[0])
+	if der == nil {
+		return nil
+	}
+
+	cert, err := parseCertificate(der)
+	if err != nil {
+		return nil
+	}
+
+	return cert
+}
+
+// isSelfSigned returns true if the certificate was signed by its own key.
+// CheckSignatureFrom is not used, since it rejects certificates without CA basic constraints (e.g. of captive portals).
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+func isWildcard(cert *x509.Certificate) bool {
+	for _, name := range cert.DNSNames {
+		if strings.HasPrefix(name, "*.") {
+			return true
 		}
+	}
+
+	return strings.HasPrefix(cert.Subject.CommonName, "*.")
+}
+
+func subjectName(cert *x509.Certificate) string {
+	if cert == nil {
+		return ""
+	}
+
+	return cert.Subject.CommonName
+}
+
+// sanList returns the sorted DNS names of the certificate joined by comma.
+// Only the first maxSANs names are included, the number of omitted names is appended (e.g. "+3").
+func sanList(cert *x509.Certificate) string {
+	if cert == nil {
+		return ""
+	}
 
-		// если O пустой — пробуем CN
-		if cn := cert.Issuer.CommonName; cn != "" {
-			return cn
+	names := append([]string{}, cert.DNSNames...)
+	sort.Strings(names)
+
+	if len(names) <= maxSANs {
+		return strings.Join(names, ",")
+	}
+
+	return strings.Join(names[:maxSANs], ",") + ",+" + strconv.Itoa(len(names)-maxSANs)
+}
+
+func parseCertificates(certs []string) []*x509.Certificate {
+	res := make([]*x509.Certificate, 0, len(certs))
+	for _, raw := range certs {
+		der := derFromCert(raw)
+		if der == nil {
+			continue
+		}
+
+		cert, err := parseCertificate(der)
+		if err != nil {
+			continue
 		}
 
-		// совсем ничего — unknown
-		return "unknown"
+		res = append(res, cert)
 	}
 
-	return "unknown"
+	return res
+}
+
+func derFromCert(raw string) []byte {
+	if block, _ := pem.Decode([]byte(raw)); block != nil {
+		return block.Bytes
+	}
+
+	// base64 DER
+	b, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil
+	}
+
+	return b
 }
 
 // Export exports a prometheus metric
 func (m *sslCertExporter) Export(res *measurement.Result, probe *probe.Probe, ch chan<- prometheus.Metric) {
 	fp := fingerprintFromResult(res)
-	issuer := issuerOrgFromResult(res)
+	issuer, issuerParsed := issuerOrgFromResult(res, m.issuerDN)
+	if !issuerParsed {
+		issuer = m.unknownIssuer
+	}
 
-	labelValues := []string{
+	values := []string{
 		m.id,
 		strconv.Itoa(probe.ID),
 		res.DstAddr(),
 		strconv.Itoa(probe.ASNForIPVersion(res.Af())),
 		strconv.Itoa(res.Af()),
 		probe.CountryCode,
-		probe.Latitude(),
-		probe.Longitude(),
+		probe.LatitudeWithPrecision(m.coordinatePrecision),
+		probe.LongitudeWithPrecision(m.coordinatePrecision),
 		fp,
 		issuer,
 	}
 
-	ver, _ := strconv.ParseFloat(res.Ver(), 64)
-	ch <- prometheus.MustNewConstMetric(sslVerDesc, prometheus.GaugeValue, ver, labelValues...)
+	leaf := leafCertificate(res)
+	if m.subjectLabels {
+		values = append(values, subjectName(leaf), sanList(leaf))
+	}
+
+	if m.dstNameLabel {
+		values = append(values, res.DstName())
+	}
+
+	if m.dstPortLabel {
+		values = append(values, res.DstPort())
+	}
+
+	if m.legacyFingerprints {
+		sha1FP, md5FP := legacyFingerprintsFromResult(res)
+		values = append(values, sha1FP, md5FP)
+	}
+
+	if m.srcAddrLabel {
+		values = append(values, res.SrcAddr())
+	}
+
+	labelValues := m.labelSet.Values(values, probe, res.Af())
+
+	if len(res.Ver()) > 0 {
+		ch <- prometheus.MustNewConstMetric(m.versionInfoDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, tlsVersionName(res.Ver()))...)
+	}
+
+	if m.legacyVersion {
+		ver, _ := strconv.ParseFloat(res.Ver(), 64)
+		ch <- prometheus.MustNewConstMetric(m.sslVerDesc, prometheus.GaugeValue, ver, labelValues...)
+	}
+
+	if m.alertLabels {
+		if res.SslcertAlert() != nil {
+			level, description := alertNames(res.SslcertAlert())
+			ch <- prometheus.MustNewConstMetric(m.alertDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, level, description)...)
+		}
+	} else {
+		var alertLevel, alertDescription float64
+		if res.SslcertAlert() != nil {
+			alertLevel = float64(res.SslcertAlert().Level())
+			alertDescription = float64(res.SslcertAlert().Description())
+		}
+		ch <- prometheus.MustNewConstMetric(m.alertLevelDesc, prometheus.GaugeValue, alertLevel, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.alertDescriptionDesc, prometheus.GaugeValue, alertDescription, labelValues...)
+	}
 
-	var alertLevel, alertDescription float64
 	if res.SslcertAlert() != nil {
-		alertLevel = float64(res.SslcertAlert().Level())
-		alertDescription = float64(res.SslcertAlert().Description())
+		var downgrade float64
+		if res.SslcertAlert().Description() == alertInappropriateFallback {
+			downgrade = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.downgradeDesc, prometheus.GaugeValue, downgrade, labelValues...)
+	}
+
+	var parsed float64
+	if issuerParsed {
+		parsed = 1
+	}
+	ch <- prometheus.MustNewConstMetric(m.issuerParsedDesc, prometheus.GaugeValue, parsed, labelValues...)
+
+	if leaf != nil {
+		ch <- prometheus.MustNewConstMetric(m.notAfterDesc, prometheus.GaugeValue, float64(leaf.NotAfter.Unix()), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.notBeforeDesc, prometheus.GaugeValue, float64(leaf.NotBefore.Unix()), labelValues...)
+
+		algorithm, bits := publicKeyInfo(leaf)
+		ch <- prometheus.MustNewConstMetric(m.keyBitsDesc, prometheus.GaugeValue, float64(bits), exporter.WithLabels(labelValues, algorithm)...)
+		ch <- prometheus.MustNewConstMetric(m.certInfoDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, leaf.SerialNumber.Text(16), hex.EncodeToString(leaf.AuthorityKeyId), hex.EncodeToString(leaf.SubjectKeyId))...)
+		ch <- prometheus.MustNewConstMetric(m.selfSignedDesc, prometheus.GaugeValue, flagValue(isSelfSigned(leaf)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.wildcardDesc, prometheus.GaugeValue, flagValue(isWildcard(leaf)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.sanCountDesc, prometheus.GaugeValue, float64(len(leaf.DNSNames)+len(leaf.IPAddresses)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.signatureAlgDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, leaf.SignatureAlgorithm.String())...)
+	}
+
+	m.exportChain(res, labelValues, ch)
+
+	if m.fingerprintChange {
+		changed, changes := fingerprintChanged(m.id+"/"+strconv.Itoa(probe.ID), res.Timestamp(), fp)
+		ch <- prometheus.MustNewConstMetric(m.fpChangedDesc, prometheus.GaugeValue, flagValue(changed), labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.certChangesDesc, prometheus.CounterValue, float64(changes), m.changesLabelSet.Values(withoutCertLabels(m.labelNames, values), probe, res.Af())...)
+	}
+
+	if m.verifier != nil && len(res.Cert()) > 0 {
+		reason := m.verifier.verify(res)
+		ch <- prometheus.MustNewConstMetric(m.chainValidDesc, prometheus.GaugeValue, flagValue(reason == ""), exporter.WithLabels(labelValues, reason)...)
+	}
+
+	if m.pinnedFingerprints != nil && len(fp) > 0 {
+		ch <- prometheus.MustNewConstMetric(m.fpMatchDesc, prometheus.GaugeValue, flagValue(m.pinnedFingerprints[fp]), labelValues...)
+	}
+
+	if m.tlsa != nil {
+		var daneValid float64
+		if verifyTLSA(m.tlsa, res.Cert()) {
+			daneValid = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.daneValidDesc, prometheus.GaugeValue, daneValid, labelValues...)
 	}
-	ch <- prometheus.MustNewConstMetric(alertLevelDesc, prometheus.GaugeValue, alertLevel, labelValues...)
-	ch <- prometheus.MustNewConstMetric(alertDescriptionDesc, prometheus.GaugeValue, alertDescription, labelValues...)
 
-	if res.Rt() > 0 {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 1, labelValues...)
-		ch <- prometheus.MustNewConstMetric(rttDesc, prometheus.GaugeValue, res.Rt(), labelValues...)
+	// a handshake took place when the probe reports an RTT or received certificates
+	ok := res.Rt() > 0 || len(res.Cert()) > 0
+	if ok {
+		ch <- prometheus.MustNewConstMetric(m.rttDesc, prometheus.GaugeValue, res.Rt(), labelValues...)
+	}
+
+	if res.Ttc() > 0 {
+		ch <- prometheus.MustNewConstMetric(m.connectTimeDesc, prometheus.GaugeValue, res.Ttc(), labelValues...)
+	}
+
+	key := m.id + "/" + strconv.Itoa(probe.ID)
+	success := exporter.Success(key, res.Timestamp(), ok, m.failureThreshold)
+
+	if m.tlsVersionLabel {
+		ch <- prometheus.MustNewConstMetric(m.successVersionDesc, prometheus.GaugeValue, success, exporter.WithLabels(labelValues, tlsVersionName(res.Ver()))...)
 	} else {
-		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, 0, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.successDesc, prometheus.GaugeValue, success, labelValues...)
+	}
+
+	if m.upDesc != nil {
+		ch <- prometheus.MustNewConstMetric(m.upDesc, prometheus.GaugeValue, success, labelValues...)
+	}
+}
+
+// exportChain exports metrics for each certificate of the served chain (certificates which can not be parsed are skipped)
+func (m *sslCertExporter) exportChain(res *measurement.Result, labelValues []string, ch chan<- prometheus.Metric) {
+	for i, raw := range res.Cert() {
+		der := derFromCert(raw)
+		if der == nil {
+			continue
+		}
+
+		cert, err := parseCertificate(der)
+		if err != nil {
+			continue
+		}
+
+		issuer, ok := issuerName(cert, m.issuerDN)
+		if !ok {
+			issuer = m.unknownIssuer
+		}
+
+		index := strconv.Itoa(i)
+		ch <- prometheus.MustNewConstMetric(m.chainInfoDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, index, fmt.Sprintf("%x", sha256.Sum256(der)), issuer)...)
+		ch <- prometheus.MustNewConstMetric(m.chainNotAfterDesc, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), exporter.WithLabels(labelValues, index)...)
+	}
+}
+
+func flagValue(set bool) float64 {
+	if set {
+		return 1
 	}
+
+	return 0
+}
+
+// tlsVersionName maps the protocol version reported by the probe (e.g. 3.3) to its name (e.g. TLSv1.2)
+func tlsVersionName(ver string) string {
+	switch ver {
+	case "":
+		return ""
+	case "2.0":
+		return "SSLv2"
+	case "3.0":
+		return "SSLv3"
+	case "3.1", "1.0":
+		return "TLSv1.0"
+	case "3.2", "1.1":
+		return "TLSv1.1"
+	case "3.3", "1.2":
+		return "TLSv1.2"
+	case "3.4", "1.3":
+		return "TLSv1.3"
+	}
+
+	return ver
 }
 
 // Describe exports metric descriptions for Prometheus
 func (m *sslCertExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- successDesc
-	ch <- rttDesc
-	ch <- sslVerDesc
-	ch <- alertLevelDesc
-	ch <- alertDescriptionDesc
+	if m.tlsVersionLabel {
+		ch <- m.successVersionDesc
+	} else {
+		ch <- m.successDesc
+	}
+	ch <- m.rttDesc
+	ch <- m.connectTimeDesc
+	ch <- m.versionInfoDesc
+	if m.alertLabels {
+		ch <- m.alertDesc
+	} else {
+		ch <- m.alertLevelDesc
+		ch <- m.alertDescriptionDesc
+	}
+	ch <- m.downgradeDesc
+	ch <- m.issuerParsedDesc
+	ch <- m.daneValidDesc
+	ch <- m.notAfterDesc
+	ch <- m.notBeforeDesc
+	ch <- m.keyBitsDesc
+	ch <- m.signatureAlgDesc
+	ch <- m.certInfoDesc
+	ch <- m.selfSignedDesc
+	ch <- m.wildcardDesc
+	ch <- m.sanCountDesc
+	ch <- m.chainInfoDesc
+	ch <- m.chainNotAfterDesc
+
+	if m.fingerprintChange {
+		ch <- m.fpChangedDesc
+		ch <- m.certChangesDesc
+	}
+
+	if m.verifier != nil {
+		ch <- m.chainValidDesc
+	}
+
+	if m.legacyVersion {
+		ch <- m.sslVerDesc
+	}
+
+	if m.pinnedFingerprints != nil {
+		ch <- m.fpMatchDesc
+	}
+
+	if m.upDesc != nil {
+		ch <- m.upDesc
+	}
 }
@@ -0,0 +1,650 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuccess(t *testing.T) {
+	cert := testCertificate(t)
+
+	tests := []struct {
+		name    string
+		rt      float64
+		cert    []string
+		success string
+	}{
+		{
+			name:    "handshake with rtt",
+			rt:      23.5,
+			cert:    []string{cert},
+			success: "1",
+		},
+		{
+			name:    "certificate received with rtt of 0",
+			rt:      0,
+			cert:    []string{cert},
+			success: "1",
+		},
+		{
+			name:    "no response",
+			rt:      0,
+			success: "0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			m := NewMeasurement("1", &config.Config{})
+			m.Add(testResult(te, test.rt, test.cert), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+			issuer := "unknown"
+			fp := ""
+			if len(test.cert) > 0 {
+				issuer = "Test CA"
+				fp = fingerprintFromResult(testResult(te, test.rt, test.cert))
+			}
+
+			expected := `
+# HELP atlas_sslcert_success Destination was reachable
+# TYPE atlas_sslcert_success gauge
+atlas_sslcert_success{asn="64496",cert_fingerprint="` + fp + `",cert_issuer="` + issuer + `",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} ` + test.success + `
+`
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_success")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestValidity(t *testing.T) {
+	cert := testCertificate(t)
+	res := testResult(t, 23.5, []string{cert})
+	leaf := leafCertificate(res)
+	if leaf == nil {
+		t.Fatal("could not parse certificate")
+	}
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	labels := `asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"`
+	expected := fmt.Sprintf(`
+# HELP atlas_sslcert_not_after_timestamp_seconds Expiry of the leaf certificate served to the probe as Unix timestamp
+# TYPE atlas_sslcert_not_after_timestamp_seconds gauge
+atlas_sslcert_not_after_timestamp_seconds{%s} %d
+# HELP atlas_sslcert_not_before_timestamp_seconds Start of the validity of the leaf certificate served to the probe as Unix timestamp
+# TYPE atlas_sslcert_not_before_timestamp_seconds gauge
+atlas_sslcert_not_before_timestamp_seconds{%s} %d
+`, labels, leaf.NotAfter.Unix(), labels, leaf.NotBefore.Unix())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_not_after_timestamp_seconds", "atlas_sslcert_not_before_timestamp_seconds")
+	assert.NoError(t, err)
+}
+
+func TestSignatureAlgorithm(t *testing.T) {
+	res := testResult(t, 23.5, []string{testCertificate(t)})
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_signature_algorithm_info Signature algorithm of the leaf certificate
+# TYPE atlas_sslcert_signature_algorithm_info gauge
+atlas_sslcert_signature_algorithm_info{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1",signature_algorithm="ECDSA-SHA256"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_signature_algorithm_info")
+	assert.NoError(t, err)
+}
+
+func TestFingerprintMatch(t *testing.T) {
+	pinned := testResult(t, 23.5, []string{testCertificate(t)})
+	fp := fingerprintFromResult(pinned)
+
+	tests := []struct {
+		name     string
+		res      *measurement.Result
+		expected string
+	}{
+		{name: "pinned", res: pinned, expected: "1"},
+		{name: "other", res: testResult(t, 23.5, []string{testCertificate(t)}), expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			cfg := &config.Config{Measurements: []config.Measurement{{ID: "1", Fingerprints: []string{strings.ToUpper(fp)}}}}
+			m := NewMeasurement("1", cfg)
+			m.Add(test.res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+			expected := `
+# HELP atlas_sslcert_fingerprint_match Fingerprint of the served leaf certificate is one of the configured fingerprints (1 = match)
+# TYPE atlas_sslcert_fingerprint_match gauge
+atlas_sslcert_fingerprint_match{asn="64496",cert_fingerprint="` + fingerprintFromResult(test.res) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} ` + test.expected + `
+`
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_fingerprint_match")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestCertInfo(t *testing.T) {
+	res := testResult(t, 23.5, []string{testCertificate(t)})
+	leaf := leafCertificate(res)
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := fmt.Sprintf(`
+# HELP atlas_sslcert_cert_info Serial number and key identifiers (hex) of the leaf certificate
+# TYPE atlas_sslcert_cert_info gauge
+atlas_sslcert_cert_info{asn="64496",authority_key_id="",cert_fingerprint="%s",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1",serial="1",subject_key_id="%x"} 1
+`, fingerprintFromResult(res), leaf.SubjectKeyId)
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_cert_info")
+	assert.NoError(t, err)
+}
+
+func TestIsSelfSigned(t *testing.T) {
+	caPEM, leafPEM := testChain(t, time.Now())
+
+	tests := []struct {
+		name     string
+		cert     string
+		expected bool
+	}{
+		{name: "self-signed leaf", cert: testCertificate(t), expected: true},
+		{name: "root", cert: caPEM, expected: true},
+		{name: "issued by CA", cert: leafPEM, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			cert := parseCertificates([]string{test.cert})[0]
+			assert.Equal(te, test.expected, isSelfSigned(cert))
+		})
+	}
+}
+
+func TestConnectTime(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   1,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"rt":       23.5,
+		"ttc":      8.25,
+		"cert":     []string{testCertificate(t)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_connect_time Time to establish the TCP connection in ms (ttc)
+# TYPE atlas_sslcert_connect_time gauge
+atlas_sslcert_connect_time{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 8.25
+`
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_connect_time")
+	assert.NoError(t, err)
+}
+
+func TestDstNameLabel(t *testing.T) {
+	res := testVerifyResult(t, "example.com", time.Now(), []string{testCertificate(t)})
+
+	m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{DstNameLabel: true}})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_success Destination was reachable
+# TYPE atlas_sslcert_success gauge
+atlas_sslcert_success{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",dst_name="example.com",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_success")
+	assert.NoError(t, err)
+}
+
+func TestDstPortLabel(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   1,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"dst_port": "8443",
+		"rt":       23.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{DstPortLabel: true}})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_success Destination was reachable
+# TYPE atlas_sslcert_success gauge
+atlas_sslcert_success{asn="64496",cert_fingerprint="",cert_issuer="unknown",country_code="DE",dst_addr="192.0.2.1",dst_port="8443",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_success")
+	assert.NoError(t, err)
+}
+
+func TestAlertLabels(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   1,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"alert":    map[string]int{"level": 2, "description": 48},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{AlertLabels: true}})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_alert Alert sent by the server (only exported for results containing an alert)
+# TYPE atlas_sslcert_alert gauge
+atlas_sslcert_alert{alert_description="unknown_ca",alert_level="fatal",asn="64496",cert_fingerprint="",cert_issuer="unknown",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`
+	err = testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_alert", "atlas_sslcert_alert_level", "atlas_sslcert_alert_description")
+	assert.NoError(t, err)
+}
+
+func TestDowngradeDetected(t *testing.T) {
+	tests := []struct {
+		name     string
+		alert    map[string]int
+		expected string
+	}{
+		{
+			name:  "inappropriate fallback",
+			alert: map[string]int{"level": 2, "description": 86},
+			expected: `
+# HELP atlas_sslcert_tls_downgrade_detected Server aborted the handshake with an inappropriate_fallback alert (only exported for results containing an alert)
+# TYPE atlas_sslcert_tls_downgrade_detected gauge
+atlas_sslcert_tls_downgrade_detected{asn="64496",cert_fingerprint="",cert_issuer="unknown",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`,
+		},
+		{
+			name:  "other alert",
+			alert: map[string]int{"level": 2, "description": 40},
+			expected: `
+# HELP atlas_sslcert_tls_downgrade_detected Server aborted the handshake with an inappropriate_fallback alert (only exported for results containing an alert)
+# TYPE atlas_sslcert_tls_downgrade_detected gauge
+atlas_sslcert_tls_downgrade_detected{asn="64496",cert_fingerprint="",cert_issuer="unknown",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 0
+`,
+		},
+		{
+			name: "no alert",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			r := map[string]interface{}{
+				"fw":        5080,
+				"type":      "sslcert",
+				"prb_id":    1,
+				"msm_id":    1,
+				"af":        4,
+				"dst_addr":  "192.0.2.1",
+				"dst_name":  "example.com",
+				"dst_port":  "443",
+				"src_addr":  "192.168.1.10",
+				"from":      "198.51.100.10",
+				"timestamp": 1700000000,
+				"method":    "TLS",
+				"ver":       "1.2",
+			}
+			if test.alert != nil {
+				r["alert"] = test.alert
+			}
+
+			b, err := json.Marshal(r)
+			if err != nil {
+				te.Fatal(err)
+			}
+			res := &measurement.Result{}
+			if err := json.Unmarshal(b, res); err != nil {
+				te.Fatal(err)
+			}
+
+			m := NewMeasurement("1", &config.Config{})
+			m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+			err = testutil.CollectAndCompare(m, strings.NewReader(test.expected), "atlas_sslcert_tls_downgrade_detected")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestCertChanges(t *testing.T) {
+	result := func(ts int, cert string) *measurement.Result {
+		b, err := json.Marshal(map[string]interface{}{
+			"type":      "sslcert",
+			"prb_id":    1,
+			"msm_id":    1540,
+			"af":        4,
+			"dst_addr":  "192.0.2.1",
+			"timestamp": ts,
+			"rt":        23.5,
+			"cert":      []string{cert},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res := &measurement.Result{}
+		if err := json.Unmarshal(b, res); err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	cfg := &config.Config{DropLabels: []string{"lat", "long"}, SSLCert: config.SSLCertConfig{FingerprintChange: true}}
+	m := NewMeasurement("1540", cfg)
+	p := &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"}
+
+	m.Add(result(1700000000, testCertificate(t)), p)
+	testutil.CollectAndCount(m)
+	m.Add(result(1700000240, testCertificate(t)), p)
+
+	expected := `
+# HELP atlas_sslcert_cert_changes_total Number of leaf certificate fingerprint changes observed for the probe
+# TYPE atlas_sslcert_cert_changes_total counter
+atlas_sslcert_cert_changes_total{asn="64496",country_code="DE",dst_addr="192.0.2.1",ip_version="4",measurement="1540",measurement_type="sslcert",probe="1"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_cert_changes_total")
+	assert.NoError(t, err)
+}
+
+func TestLegacyFingerprints(t *testing.T) {
+	cert := testCertificate(t)
+	res := testVerifyResult(t, "example.com", time.Now(), []string{cert})
+	der := derFromCert(cert)
+
+	m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{LegacyFingerprints: true}})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_success Destination was reachable
+# TYPE atlas_sslcert_success gauge
+atlas_sslcert_success{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_fingerprint_md5="` + fmt.Sprintf("%x", md5.Sum(der)) + `",cert_fingerprint_sha1="` + fmt.Sprintf("%x", sha1.Sum(der)) + `",cert_issuer="Test CA",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_success")
+	assert.NoError(t, err)
+}
+
+func TestVersion(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   1,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"rt":       23.5,
+		"ver":      "3.3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := `asn="64496",cert_fingerprint="",cert_issuer="unknown",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"`
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		expected string
+	}{
+		{
+			name: "default",
+			cfg:  &config.Config{},
+			expected: `
+# HELP atlas_sslcert_version_info SSL/TLS version used for the request
+# TYPE atlas_sslcert_version_info gauge
+atlas_sslcert_version_info{` + labels + `,tls_version="TLSv1.2"} 1
+`,
+		},
+		{
+			name: "legacy",
+			cfg:  &config.Config{SSLCert: config.SSLCertConfig{LegacyVersionMetric: true}},
+			expected: `
+# HELP atlas_sslcert_version SSL/TLS version used for the request
+# TYPE atlas_sslcert_version gauge
+atlas_sslcert_version{` + labels + `} 3.3
+# HELP atlas_sslcert_version_info SSL/TLS version used for the request
+# TYPE atlas_sslcert_version_info gauge
+atlas_sslcert_version_info{` + labels + `,tls_version="TLSv1.2"} 1
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			m := NewMeasurement("1", test.cfg)
+			m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+			err := testutil.CollectAndCompare(m, strings.NewReader(test.expected), "atlas_sslcert_version", "atlas_sslcert_version_info")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestIssuerName(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   pkix.Name
+		fullDN   bool
+		expected string
+		ok       bool
+	}{
+		{name: "organization", issuer: pkix.Name{CommonName: "R3", Organization: []string{"Let's Encrypt"}, Country: []string{"US"}}, expected: "Let's Encrypt", ok: true},
+		{name: "common name", issuer: pkix.Name{CommonName: "R3"}, expected: "R3", ok: true},
+		{name: "empty", issuer: pkix.Name{}, expected: "", ok: false},
+		{name: "full DN", issuer: pkix.Name{CommonName: "R3", Organization: []string{"Let's Encrypt"}, Country: []string{"US"}}, fullDN: true, expected: "CN=R3,O=Let's Encrypt,C=US", ok: true},
+		{name: "empty full DN", issuer: pkix.Name{}, fullDN: true, expected: "", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer, ok := issuerName(&x509.Certificate{Issuer: test.issuer}, test.fullDN)
+			assert.Equal(t, test.expected, issuer)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
+
+func TestUnknownIssuer(t *testing.T) {
+	tests := []struct {
+		name     string
+		certs    []string
+		issuer   string
+		expected string
+	}{
+		{
+			name:     "issuer parsed",
+			certs:    []string{testCertificate(t)},
+			issuer:   "Test CA",
+			expected: "1",
+		},
+		{
+			name:     "issuer without organization and common name",
+			certs:    []string{testCertificateWithSubject(t, pkix.Name{})},
+			issuer:   "n/a",
+			expected: "0",
+		},
+		{
+			name:     "no certificate",
+			issuer:   "n/a",
+			expected: "0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			res := testResult(te, 23.5, test.certs)
+			m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{UnknownIssuer: "n/a"}})
+			m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+			expected := `
+# HELP atlas_sslcert_cert_issuer_parsed Issuer could be extracted from the certificate (0 = fallback value used)
+# TYPE atlas_sslcert_cert_issuer_parsed gauge
+atlas_sslcert_cert_issuer_parsed{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="` + test.issuer + `",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} ` + test.expected + `
+`
+			err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_cert_issuer_parsed")
+			assert.NoError(te, err)
+		})
+	}
+}
+
+func TestIsWildcard(t *testing.T) {
+	assert.True(t, isWildcard(&x509.Certificate{DNSNames: []string{"example.com", "*.example.com"}}))
+	assert.True(t, isWildcard(&x509.Certificate{Subject: pkix.Name{CommonName: "*.example.com"}}))
+	assert.False(t, isWildcard(&x509.Certificate{DNSNames: []string{"example.com", "www.example.com"}}))
+}
+
+func TestChain(t *testing.T) {
+	certs := []string{testCertificate(t), "invalid", testCertificate(t)}
+	res := testResult(t, 23.5, certs)
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_chain_cert_info Certificate of the chain served to the probe (chain_index = position in the chain, 0 = leaf)
+# TYPE atlas_sslcert_chain_cert_info gauge
+`
+	for _, i := range []int{0, 2} {
+		parsed := parseCertificates([]string{certs[i]})[0]
+		expected += fmt.Sprintf(`atlas_sslcert_chain_cert_info{asn="64496",cert_fingerprint="%s",cert_issuer="Test CA",chain_index="%d",country_code="DE",dst_addr="192.0.2.1",fingerprint="%x",ip_version="4",issuer="Test CA",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`, fingerprintFromResult(res), i, sha256.Sum256(parsed.Raw))
+	}
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_chain_cert_info")
+	assert.NoError(t, err)
+}
+
+func TestSANList(t *testing.T) {
+	tests := []struct {
+		names    []string
+		expected string
+	}{
+		{names: nil, expected: ""},
+		{names: []string{"b.example.com", "a.example.com"}, expected: "a.example.com,b.example.com"},
+		{names: []string{"g", "f", "e", "d", "c", "b", "a"}, expected: "a,b,c,d,e,+2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(te *testing.T) {
+			assert.Equal(te, test.expected, sanList(&x509.Certificate{DNSNames: test.names}))
+		})
+	}
+}
+
+func TestSubjectLabels(t *testing.T) {
+	res := testResult(t, 23.5, []string{testCertificate(t)})
+
+	m := NewMeasurement("1", &config.Config{SSLCert: config.SSLCertConfig{SubjectLabels: true}})
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_sslcert_success Destination was reachable
+# TYPE atlas_sslcert_success gauge
+atlas_sslcert_success{asn="64496",cert_fingerprint="` + fingerprintFromResult(res) + `",cert_issuer="Test CA",cert_san="example.com",cert_subject="example.com",country_code="DE",dst_addr="192.0.2.1",ip_version="4",lat="",long="",measurement="1",measurement_type="sslcert",probe="1"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_success")
+	assert.NoError(t, err)
+}
+
+func testResult(t *testing.T, rt float64, certs []string) *measurement.Result {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":     "sslcert",
+		"prb_id":   1,
+		"msm_id":   1,
+		"af":       4,
+		"dst_addr": "192.0.2.1",
+		"rt":       rt,
+		"cert":     certs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func testCertificate(t *testing.T) string {
+	return testCertificateWithSubject(t, pkix.Name{CommonName: "example.com", Organization: []string{"Test CA"}})
+}
+
+func testCertificateWithSubject(t *testing.T, subject pkix.Name) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      subject,
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
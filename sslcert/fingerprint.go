@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/exporter"
+)
+
+const fingerprintStateTTL = 24 * time.Hour
+
+var fingerprints = exporter.NewStateStore[fingerprintState](fingerprintStateTTL)
+
+type fingerprintState struct {
+	timestamp   int
+	fingerprint string
+	changed     bool
+	changes     int
+}
+
+// fingerprintChanged returns true if the fingerprint differs from the one of the previous result for the key
+// and the number of changes observed for the key so far. Results without certificate do not affect the state.
+func fingerprintChanged(key string, timestamp int, fingerprint string) (bool, int) {
+	s := fingerprints.Update(key, func(s fingerprintState, found bool) fingerprintState {
+		if found && s.timestamp == timestamp {
+			return s
+		}
+
+		if len(fingerprint) == 0 {
+			s.changed = false
+			return s
+		}
+
+		s.changed = found && len(s.fingerprint) > 0 && s.fingerprint != fingerprint
+		s.timestamp = timestamp
+		s.fingerprint = fingerprint
+		if s.changed {
+			s.changes++
+		}
+
+		return s
+	})
+
+	return s.changed, s.changes
+}
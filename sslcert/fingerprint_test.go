@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import "testing"
+
+func TestFingerprintChanged(t *testing.T) {
+	steps := []struct {
+		timestamp   int
+		fingerprint string
+		expected    bool
+		changes     int
+	}{
+		{timestamp: 1, fingerprint: "aa", expected: false, changes: 0},
+		{timestamp: 2, fingerprint: "aa", expected: false, changes: 0},
+		{timestamp: 3, fingerprint: "bb", expected: true, changes: 1},
+		{timestamp: 3, fingerprint: "bb", expected: true, changes: 1},
+		{timestamp: 4, fingerprint: "", expected: false, changes: 1},
+		{timestamp: 5, fingerprint: "bb", expected: false, changes: 1},
+		{timestamp: 6, fingerprint: "aa", expected: true, changes: 2},
+	}
+
+	for i, s := range steps {
+		got, changes := fingerprintChanged("test/1", s.timestamp, s.fingerprint)
+		if got != s.expected {
+			t.Fatalf("step %d: expected %v, got %v", i, s.expected, got)
+		}
+		if changes != s.changes {
+			t.Fatalf("step %d: expected %d changes, got %d", i, s.changes, changes)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicKeyInfo(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		key       interface{}
+		algorithm string
+		bits      int
+	}{
+		{name: "RSA", key: &rsaKey.PublicKey, algorithm: "RSA", bits: 2048},
+		{name: "ECDSA", key: &ecKey.PublicKey, algorithm: "ECDSA", bits: 384},
+		{name: "Ed25519", key: edKey, algorithm: "Ed25519", bits: 256},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			algorithm, bits := publicKeyInfo(&x509.Certificate{PublicKey: test.key})
+			assert.Equal(te, test.algorithm, algorithm)
+			assert.Equal(te, test.bits, bits)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"math"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var notAfterSpreadDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(ns, sub, "cert_not_after_spread"),
+	"Spread of the expiry (unix time) of the leaf certificates served to the probes for a target (stat = min, max or stddev in seconds)",
+	[]string{"measurement", "target", "stat"},
+	constLabels,
+)
+
+type notAfterSpread struct {
+	id string
+}
+
+// Aggregate exports min, max and standard deviation of the leaf certificate expiry per target (only if more than one probe reported).
+// Results without a valid leaf certificate are skipped.
+func (a *notAfterSpread) Aggregate(results []*measurement.Result, ch chan<- prometheus.Metric) {
+	notAfter := make(map[string][]float64)
+	for _, res := range results {
+		leaf := leafCertificate(res)
+		if leaf == nil {
+			continue
+		}
+
+		target := targetFromResult(res)
+		notAfter[target] = append(notAfter[target], float64(leaf.NotAfter.Unix()))
+	}
+
+	for target, values := range notAfter {
+		if len(values) < 2 {
+			continue
+		}
+
+		min, max, stddev := spread(values)
+		ch <- prometheus.MustNewConstMetric(notAfterSpreadDesc, prometheus.GaugeValue, min, a.id, target, "min")
+		ch <- prometheus.MustNewConstMetric(notAfterSpreadDesc, prometheus.GaugeValue, max, a.id, target, "max")
+		ch <- prometheus.MustNewConstMetric(notAfterSpreadDesc, prometheus.GaugeValue, stddev, a.id, target, "stddev")
+	}
+}
+
+// Describe exports metric descriptions for Prometheus
+func (a *notAfterSpread) Describe(ch chan<- *prometheus.Desc) {
+	ch <- notAfterSpreadDesc
+}
+
+// targetFromResult returns the name of the target of the measurement (or the address if no name was given)
+func targetFromResult(res *measurement.Result) string {
+	if len(res.DstName()) > 0 {
+		return res.DstName()
+	}
+
+	return res.DstAddr()
+}
+
+func spread(values []float64) (min, max, stddev float64) {
+	min, max = values[0], values[0]
+
+	var sum float64
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		sum += v
+	}
+
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+
+	return min, max, math.Sqrt(sq / float64(len(values)))
+}
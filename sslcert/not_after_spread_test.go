@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpread(t *testing.T) {
+	min, max, stddev := spread([]float64{100, 100, 100, 500})
+
+	assert.Equal(t, float64(100), min)
+	assert.Equal(t, float64(500), max)
+	assert.InDelta(t, 173.2, stddev, 0.1)
+}
+
+func TestNotAfterSpread(t *testing.T) {
+	caPEM, leafPEM := testChain(t, time.Now())
+	leaf := parseCertificates([]string{leafPEM})[0]
+
+	m := NewMeasurement("1", &config.Config{})
+	m.Add(testProbeResult(t, 1, []string{leafPEM, caPEM}), &probe.Probe{ID: 1})
+	m.Add(testProbeResult(t, 2, []string{leafPEM, caPEM}), &probe.Probe{ID: 2})
+	m.Add(testProbeResult(t, 3, []string{"invalid", caPEM}), &probe.Probe{ID: 3})
+
+	expected := fmt.Sprintf(`
+# HELP atlas_sslcert_cert_not_after_spread Spread of the expiry (unix time) of the leaf certificates served to the probes for a target (stat = min, max or stddev in seconds)
+# TYPE atlas_sslcert_cert_not_after_spread gauge
+atlas_sslcert_cert_not_after_spread{measurement="1",measurement_type="sslcert",stat="max",target="192.0.2.1"} %d
+atlas_sslcert_cert_not_after_spread{measurement="1",measurement_type="sslcert",stat="min",target="192.0.2.1"} %d
+atlas_sslcert_cert_not_after_spread{measurement="1",measurement_type="sslcert",stat="stddev",target="192.0.2.1"} 0
+`, leaf.NotAfter.Unix(), leaf.NotAfter.Unix())
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_sslcert_cert_not_after_spread")
+	assert.NoError(t, err)
+}
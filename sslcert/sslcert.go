@@ -14,11 +14,33 @@ const (
 
 // NewMeasurement returns a new instance of `exorter.Measurement` for a SSL measurement
 func NewMeasurement(id string, cfg *config.Config) *exporter.Measurement {
-	opts := []exporter.MeasurementOpt{}
+	opts := []exporter.MeasurementOpt{
+		exporter.WithAggregators(&notAfterSpread{id: id}, &certDivergence{id: id}),
+	}
 
 	if cfg.FilterInvalidResults {
 		opts = append(opts, exporter.WithValidator(&exporter.DefaultResultValidator{}))
 	}
 
-	return exporter.NewMeasurement(&sslCertExporter{id}, opts...)
+	if cfg.MinFirmware > 0 {
+		opts = append(opts, exporter.WithMinFirmware(id, sub, cfg.MinFirmware))
+	}
+
+	e := newSSLCertExporter(id, cfg)
+	if cfg.SSLCert.VerifyChain {
+		e.verifier = &chainVerifier{roots: cfg.SSLCert.RootCAs()}
+	}
+
+	if mc := cfg.MeasurementByID(id); mc != nil {
+		e.tlsa = mc.TLSA
+
+		if len(mc.Fingerprints) > 0 {
+			e.pinnedFingerprints = make(map[string]bool)
+			for _, fp := range mc.Fingerprints {
+				e.pinnedFingerprints[normalizeFingerprint(fp)] = true
+			}
+		}
+	}
+
+	return exporter.NewMeasurement(e, opts...)
 }
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+// reasons of failed chain verifications
+const (
+	chainExpired          = "expired"
+	chainUnknownAuthority = "unknown_authority"
+	chainHostnameMismatch = "hostname_mismatch"
+	chainInvalid          = "invalid_certificate"
+)
+
+// chainVerifier verifies the certificate chains served to the probes, since probes do not validate them
+type chainVerifier struct {
+	// roots used for verification (nil = system roots)
+	roots *x509.CertPool
+}
+
+// verify verifies the chain of the result at the time of the result and returns the reason of the failure (empty if valid)
+func (v *chainVerifier) verify(res *measurement.Result) string {
+	certs := res.Cert()
+	leaf := leafCertificate(res)
+	if leaf == nil {
+		return chainInvalid
+	}
+
+	intermediates := x509.NewCertPool()
+	if len(certs) > 1 {
+		for _, cert := range parseCertificates(certs[1:]) {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       res.DstName(),
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Unix(int64(res.Timestamp()), 0),
+	})
+
+	return verificationFailureReason(err)
+}
+
+func verificationFailureReason(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+		return chainExpired
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return chainUnknownAuthority
+	}
+
+	var hostname x509.HostnameError
+	if errors.As(err, &hostname) {
+		return chainHostnameMismatch
+	}
+
+	return chainInvalid
+}
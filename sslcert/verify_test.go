@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package sslcert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainVerifier(t *testing.T) {
+	now := time.Now()
+	caPEM, leafPEM := testChain(t, now)
+
+	file := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(file, []byte(caPEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{SSLCert: config.SSLCertConfig{VerifyChain: true, RootCAFile: file}}
+	if errs := cfg.Validate(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	v := &chainVerifier{roots: cfg.SSLCert.RootCAs()}
+
+	tests := []struct {
+		name      string
+		dstName   string
+		timestamp time.Time
+		certs     []string
+		expected  string
+	}{
+		{
+			name:      "valid",
+			dstName:   "example.com",
+			timestamp: now,
+			certs:     []string{leafPEM},
+			expected:  "",
+		},
+		{
+			name:      "hostname mismatch",
+			dstName:   "example.org",
+			timestamp: now,
+			certs:     []string{leafPEM},
+			expected:  chainHostnameMismatch,
+		},
+		{
+			name:      "expired",
+			dstName:   "example.com",
+			timestamp: now.Add(48 * time.Hour),
+			certs:     []string{leafPEM},
+			expected:  chainExpired,
+		},
+		{
+			name:      "unknown authority",
+			dstName:   "example.com",
+			timestamp: now,
+			certs:     []string{testCertificate(t)},
+			expected:  chainUnknownAuthority,
+		},
+		{
+			name:      "invalid certificate",
+			dstName:   "example.com",
+			timestamp: now,
+			certs:     []string{"invalid"},
+			expected:  chainInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(te *testing.T) {
+			res := testVerifyResult(te, test.dstName, test.timestamp, test.certs)
+			assert.Equal(te, test.expected, v.verify(res))
+		})
+	}
+}
+
+func testVerifyResult(t *testing.T, dstName string, timestamp time.Time, certs []string) *measurement.Result {
+	b, err := json.Marshal(map[string]interface{}{
+		"type":      "sslcert",
+		"prb_id":    1,
+		"msm_id":    1,
+		"af":        4,
+		"dst_addr":  "192.0.2.1",
+		"dst_name":  dstName,
+		"timestamp": timestamp.Unix(),
+		"cert":      certs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &measurement.Result{}
+	if err := json.Unmarshal(b, res); err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+// testChain returns a CA certificate and a leaf certificate for example.com issued by it (both PEM encoded)
+func testChain(t *testing.T, now time.Time) (string, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root", Organization: []string{"Test CA"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}))
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	rtraceroute "github.com/DNS-OARC/ripeatlas/measurement/traceroute"
+	"github.com/czerwonk/atlas_exporter/asn"
+	"github.com/czerwonk/atlas_exporter/lookup"
+	log "github.com/sirupsen/logrus"
+)
+
+const unresolvedASN = "*"
+
+// asPath returns the ordered ASNs of the hops. Consecutive hops in the same AS are merged,
+// hops not responding or not resolvable are represented by a placeholder.
+// ok is false if the lookup of a hop address has not completed yet.
+func asPath(res *measurement.Result, r asn.Resolver) (path []string, ok bool) {
+	path = make([]string, 0)
+	for _, hop := range res.TracerouteResults() {
+		a, pending := hopASN(hop.Replies(), r)
+		if pending {
+			return nil, false
+		}
+
+		if len(path) > 0 && path[len(path)-1] == a {
+			continue
+		}
+
+		path = append(path, a)
+	}
+
+	return path, true
+}
+
+// penultimateHopASN returns the ASN of the hop before the last hop (if the destination was reached and the hop could be resolved)
+func penultimateHopASN(res *measurement.Result, r asn.Resolver) (int, bool) {
+	hops := res.TracerouteResults()
+	if len(hops) < 2 {
+		return 0, false
+	}
+
+	if success, _ := processLastHop(res); success == 0 {
+		return 0, false
+	}
+
+	s, pending := hopASN(hops[len(hops)-2].Replies(), r)
+	if pending {
+		return 0, false
+	}
+
+	a, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+
+	return a, true
+}
+
+// asPathLength returns the number of distinct ASes of the path
+func asPathLength(path []string) int {
+	seen := make(map[string]bool)
+	for _, a := range path {
+		if a != unresolvedASN {
+			seen[a] = true
+		}
+	}
+
+	return len(seen)
+}
+
+// hopASN returns the ASN of the first resolvable public address of the replies (pending is true if a lookup has not completed yet)
+func hopASN(replies []*rtraceroute.Reply, r asn.Resolver) (a string, pending bool) {
+	for _, rep := range replies {
+		ip := net.ParseIP(rep.From())
+		if ip == nil || !asn.IsPublic(ip) {
+			continue
+		}
+
+		a, err := r.ASN(ip)
+		if errors.Is(err, lookup.ErrPending) {
+			return unresolvedASN, true
+		}
+
+		if err != nil {
+			log.Debugf("could not resolve ASN for %s: %v", ip, err)
+			continue
+		}
+
+		if a > 0 {
+			return strconv.Itoa(a), false
+		}
+	}
+
+	return unresolvedASN, false
+}
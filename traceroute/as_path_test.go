@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/lookup"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticResolver map[string]int
+
+func (r staticResolver) ASN(ip net.IP) (int, error) {
+	return r[ip.String()], nil
+}
+
+type pendingResolver struct{}
+
+func (r pendingResolver) ASN(ip net.IP) (int, error) {
+	return 0, lookup.ErrPending
+}
+
+func TestASPath(t *testing.T) {
+	res := &measurement.Result{}
+	err := json.Unmarshal([]byte(`{
+		"type": "traceroute",
+		"af": 4,
+		"dst_addr": "8.8.8.8",
+		"result": [
+			{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+			{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}]},
+			{"hop": 3, "result": [{"from": "198.51.100.2", "rtt": 6.3}]},
+			{"hop": 4, "result": [{"x": "*"}, {"x": "*"}]},
+			{"hop": 5, "result": [{"from": "203.0.113.1", "rtt": 8.1}]},
+			{"hop": 6, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+		]
+	}`), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := staticResolver{
+		"198.51.100.1": 3356,
+		"198.51.100.2": 3356,
+		"203.0.113.1":  174,
+		"8.8.8.8":      15169,
+	}
+
+	expected := "* 3356 * 174 15169"
+	path, ok := asPath(res, r)
+	if got := strings.Join(path, " "); !ok || got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	if got := asPathLength(path); got != 3 {
+		t.Fatalf("expected AS path length 3, got %d", got)
+	}
+
+	if got, ok := penultimateHopASN(res, r); !ok || got != 174 {
+		t.Fatalf("expected penultimate hop ASN 174, got %d", got)
+	}
+}
+
+func TestASPathPending(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+
+	if _, ok := asPath(res, pendingResolver{}); ok {
+		t.Fatal("expected no AS path while lookups are pending")
+	}
+
+	if _, ok := penultimateHopASN(res, pendingResolver{}); ok {
+		t.Fatal("expected no penultimate hop ASN while lookups are pending")
+	}
+}
+
+type failingResolver map[string]int
+
+func (r failingResolver) ASN(ip net.IP) (int, error) {
+	if a, found := r[ip.String()]; found {
+		return a, nil
+	}
+
+	return 0, errors.New("service unavailable")
+}
+
+func TestPenultimateHopASNResolverError(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "198.51.100.1", "rtt": 5.2}]},
+		{"hop": 2, "result": [{"from": "203.0.113.1", "rtt": 8.1}]},
+		{"hop": 3, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+
+	e := newTracerouteExporter("1", &config.Config{Traceroute: config.TracerouteConfig{ASPath: true}})
+	e.asnResolver = failingResolver{"198.51.100.1": 3356, "8.8.8.8": 15169}
+	m := exporter.NewMeasurement(e)
+	m.Add(res, &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_traceroute_as_path AS path derived from the hop addresses (* = hop not responding or not resolvable)
+# TYPE atlas_traceroute_as_path gauge
+atlas_traceroute_as_path{as_path="3356 * 15169",asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",ip_version="4",lat="",long="",measurement="1",measurement_type="traceroute",probe="1",protocol="ICMP"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_traceroute_as_path", "atlas_traceroute_penultimate_hop_asn")
+	assert.NoError(t, err)
+}
@@ -4,34 +4,116 @@ package traceroute
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/asn"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/geo"
 	"github.com/czerwonk/atlas_exporter/probe"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
-	labels      []string
-	successDesc *prometheus.Desc
-	hopDesc     *prometheus.Desc
-	rttDesc     *prometheus.Desc
-)
+	constLabels = prometheus.Labels{"measurement_type": sub}
 
-func init() {
 	labels = []string{"measurement", "probe", "dst_addr", "dst_name", "asn", "ip_version", "protocol", "country_code", "lat", "long"}
+)
+
+type tracerouteExporter struct {
+	id                  string
+	coordinatePrecision int
+	failureThreshold    int
+	hopRTT              bool
+	hopLoss             bool
+	mpls                bool
+	pathChanges         bool
+	hopCountries        bool
+	hopCities           bool
+	parisIDLabel        bool
+	srcAddrLabel        bool
+	asnResolver         asn.Resolver
+	geoResolver         geo.Resolver
+	labelSet            *exporter.LabelSet
 
-	successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", labels, nil)
-	hopDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hops"), "Number of hops", labels, nil)
-	rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", labels, nil)
+	successDesc      *prometheus.Desc
+	upDesc           *prometheus.Desc
+	hopDesc          *prometheus.Desc
+	rttDesc          *prometheus.Desc
+	asPathDesc       *prometheus.Desc
+	asPathLenDesc    *prometheus.Desc
+	penultimateDesc  *prometheus.Desc
+	hopRttDesc       *prometheus.Desc
+	hopLossDesc      *prometheus.Desc
+	mplsDepthDesc    *prometheus.Desc
+	mplsLabelDesc    *prometheus.Desc
+	reachedDesc      *prometheus.Desc
+	unresponsiveDesc *prometheus.Desc
+	pathIDDesc       *prometheus.Desc
+	lastHopDesc      *prometheus.Desc
+	pathChangesDesc  *prometheus.Desc
+	icmpErrorDesc    *prometheus.Desc
+	lateDesc         *prometheus.Desc
+	countryHopsDesc  *prometheus.Desc
+	cityHopsDesc     *prometheus.Desc
 }
 
-type tracerouteExporter struct {
-	id string
+// newTracerouteExporter returns a new exporter (the labels of the metrics depend on the config)
+func newTracerouteExporter(id string, cfg *config.Config) *tracerouteExporter {
+	e := &tracerouteExporter{
+		id:                  id,
+		coordinatePrecision: cfg.LatLongPrecision(),
+		failureThreshold:    cfg.FailureThreshold,
+		hopRTT:              cfg.Traceroute.HopRTT,
+		hopLoss:             cfg.Traceroute.HopLoss,
+		mpls:                cfg.Traceroute.MPLS,
+		pathChanges:         cfg.Traceroute.PathChanges,
+		hopCountries:        cfg.Traceroute.HopCountries,
+		hopCities:           cfg.Traceroute.HopCities,
+		parisIDLabel:        cfg.Traceroute.ParisIDLabel,
+		srcAddrLabel:        cfg.SrcAddrLabel,
+	}
+
+	names := exporter.WithLabels(labels)
+	if e.parisIDLabel {
+		names = append(names, "paris_id")
+	}
+	if e.srcAddrLabel {
+		names = append(names, "src_addr")
+	}
+	e.labelSet = exporter.NewLabelSet(names, cfg)
+
+	l := e.labelSet.Names()
+	e.successDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "success"), "Destination was reachable", l, constLabels)
+	if cfg.UpMetric {
+		e.upDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "up"), "Destination was reachable (same as success)", l, constLabels)
+	}
+	e.hopDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hops"), "Number of hops", l, constLabels)
+	e.hopLossDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hop_loss_ratio"), "Ratio of packets sent to the hop without reply (timeouts)", exporter.WithLabels(l, "hop"), constLabels)
+	e.mplsDepthDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hop_mpls_stack_depth"), "Number of MPLS labels reported by the hop in ICMP extensions", exporter.WithLabels(l, "hop", "hop_addr"), constLabels)
+	e.mplsLabelDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hop_mpls_top_label"), "Top MPLS label reported by the hop in ICMP extensions", exporter.WithLabels(l, "hop", "hop_addr"), constLabels)
+	e.reachedDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "destination_reached"), "Last hop answered from the destination address (not affected by failure_threshold)", l, constLabels)
+	e.pathIDDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "path_id"), "FNV-1a hash of the sequence of hop addresses (changes on reroutes)", l, constLabels)
+	e.unresponsiveDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "unresponsive_hops"), "Number of hops without any reply", l, constLabels)
+	e.lastHopDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "last_responding_hop"), "Last hop with a reply if the destination was not reached (value is the hop index)", exporter.WithLabels(l, "hop", "hop_addr"), constLabels)
+	e.pathChangesDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "path_changes_total"), "Number of changes of the sequence of hop addresses observed for the probe", l, constLabels)
+	e.icmpErrorDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "icmp_errors"), "Number of ICMP unreachable replies by error (only exported for errors contained in the result)", exporter.WithLabels(l, "error"), constLabels)
+	e.lateDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "late_replies"), "Number of replies received late (after the reply to a later packet)", l, constLabels)
+	e.rttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "rtt"), "Round trip time in ms", l, constLabels)
+	e.asPathDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "as_path"), "AS path derived from the hop addresses (* = hop not responding or not resolvable)", exporter.WithLabels(l, "as_path"), constLabels)
+	e.asPathLenDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "as_path_length"), "Number of ASes in the AS path derived from the hop addresses (hops not responding or not resolvable are not counted)", l, constLabels)
+	e.countryHopsDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "country_hops"), "Number of hops located in the country", exporter.WithLabels(l, "hop_country"), constLabels)
+	e.cityHopsDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "city_hops"), "Number of hops located in the city", exporter.WithLabels(l, "hop_country", "hop_city"), constLabels)
+	e.penultimateDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "penultimate_hop_asn"), "ASN of the hop before the destination (only exported if the destination was reached and the hop could be resolved)", l, constLabels)
+	e.hopRttDesc = prometheus.NewDesc(prometheus.BuildFQName(ns, sub, "hop_rtt"), "Round trip time in ms of the hop (minimum of the replies from the address)", exporter.WithLabels(l, "hop", "hop_addr"), constLabels)
+
+	return e
 }
 
 // Export exports a prometheus metric
 func (m *tracerouteExporter) Export(res *measurement.Result, probe *probe.Probe, ch chan<- prometheus.Metric) {
-	labelValues := []string{
+	values := []string{
 		m.id,
 		strconv.Itoa(probe.ID),
 		res.DstAddr(),
@@ -40,23 +122,153 @@ func (m *tracerouteExporter) Export(res *measurement.Result, probe *probe.Probe,
 		strconv.Itoa(res.Af()),
 		res.Proto(),
 		probe.CountryCode,
-		probe.Latitude(),
-		probe.Longitude(),
+		probe.LatitudeWithPrecision(m.coordinatePrecision),
+		probe.LongitudeWithPrecision(m.coordinatePrecision),
+	}
+
+	if m.parisIDLabel {
+		values = append(values, strconv.Itoa(res.ParisId()))
+	}
+
+	if m.srcAddrLabel {
+		values = append(values, res.SrcAddr())
 	}
 
+	labelValues := m.labelSet.Values(values, probe, res.Af())
+
 	success, rtt := processLastHop(res)
 	hops := float64(len(res.TracerouteResults()))
-	ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, success, labelValues...)
-	ch <- prometheus.MustNewConstMetric(hopDesc, prometheus.GaugeValue, hops, labelValues...)
+	key := m.id + "/" + strconv.Itoa(probe.ID)
+	up := exporter.Success(key, res.Timestamp(), success == 1, m.failureThreshold)
+	ch <- prometheus.MustNewConstMetric(m.successDesc, prometheus.GaugeValue, up, labelValues...)
+
+	if m.upDesc != nil {
+		ch <- prometheus.MustNewConstMetric(m.upDesc, prometheus.GaugeValue, up, labelValues...)
+	}
+	ch <- prometheus.MustNewConstMetric(m.hopDesc, prometheus.GaugeValue, hops, labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.unresponsiveDesc, prometheus.GaugeValue, float64(unresponsiveHops(res)), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.lateDesc, prometheus.GaugeValue, float64(lateReplies(res)), labelValues...)
+	ch <- prometheus.MustNewConstMetric(m.reachedDesc, prometheus.GaugeValue, success, labelValues...)
+
+	if success == 0 {
+		if hop, addr, ok := lastRespondingHop(res); ok {
+			ch <- prometheus.MustNewConstMetric(m.lastHopDesc, prometheus.GaugeValue, float64(hop), exporter.WithLabels(labelValues, strconv.Itoa(hop), addr)...)
+		}
+	}
+	id := pathID(res)
+	ch <- prometheus.MustNewConstMetric(m.pathIDDesc, prometheus.GaugeValue, float64(id), labelValues...)
+
+	if m.pathChanges {
+		ch <- prometheus.MustNewConstMetric(m.pathChangesDesc, prometheus.CounterValue, float64(pathChanges(key, res.Timestamp(), id)), labelValues...)
+	}
+
+	for _, e := range icmpErrors(res) {
+		ch <- prometheus.MustNewConstMetric(m.icmpErrorDesc, prometheus.GaugeValue, float64(e.count), exporter.WithLabels(labelValues, e.name)...)
+	}
 
 	if rtt > 0 {
-		ch <- prometheus.MustNewConstMetric(rttDesc, prometheus.GaugeValue, rtt, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.rttDesc, prometheus.GaugeValue, rtt, labelValues...)
+	}
+
+	if m.asnResolver != nil {
+		if path, ok := asPath(res, m.asnResolver); ok {
+			ch <- prometheus.MustNewConstMetric(m.asPathDesc, prometheus.GaugeValue, 1, exporter.WithLabels(labelValues, strings.Join(path, " "))...)
+			ch <- prometheus.MustNewConstMetric(m.asPathLenDesc, prometheus.GaugeValue, float64(asPathLength(path)), labelValues...)
+		}
+
+		if a, ok := penultimateHopASN(res, m.asnResolver); ok {
+			ch <- prometheus.MustNewConstMetric(m.penultimateDesc, prometheus.GaugeValue, float64(a), labelValues...)
+		}
+	}
+
+	if m.geoResolver != nil {
+		m.exportHopLocations(res, labelValues, ch)
+	}
+
+	if m.hopRTT {
+		for _, h := range hopRTTs(res) {
+			ch <- prometheus.MustNewConstMetric(m.hopRttDesc, prometheus.GaugeValue, h.rtt, exporter.WithLabels(labelValues, strconv.Itoa(h.hop), h.addr)...)
+		}
+	}
+
+	if m.hopLoss {
+		for _, h := range hopLossRatios(res) {
+			ch <- prometheus.MustNewConstMetric(m.hopLossDesc, prometheus.GaugeValue, h.ratio, exporter.WithLabels(labelValues, strconv.Itoa(h.hop))...)
+		}
+	}
+
+	if m.mpls {
+		for _, h := range hopMPLSStacks(res) {
+			l := exporter.WithLabels(labelValues, strconv.Itoa(h.hop), h.addr)
+			ch <- prometheus.MustNewConstMetric(m.mplsDepthDesc, prometheus.GaugeValue, float64(h.depth), l...)
+			ch <- prometheus.MustNewConstMetric(m.mplsLabelDesc, prometheus.GaugeValue, float64(h.topLabel), l...)
+		}
+	}
+}
+
+func (m *tracerouteExporter) exportHopLocations(res *measurement.Result, labelValues []string, ch chan<- prometheus.Metric) {
+	locations, ok := hopLocations(res, m.geoResolver)
+	if !ok {
+		return
+	}
+
+	if m.hopCountries {
+		for _, h := range countHops(locations, false) {
+			ch <- prometheus.MustNewConstMetric(m.countryHopsDesc, prometheus.GaugeValue, float64(h.hops), exporter.WithLabels(labelValues, h.location.Country)...)
+		}
+	}
+
+	if m.hopCities {
+		for _, h := range countHops(locations, true) {
+			ch <- prometheus.MustNewConstMetric(m.cityHopsDesc, prometheus.GaugeValue, float64(h.hops), exporter.WithLabels(labelValues, h.location.Country, h.location.City)...)
+		}
 	}
 }
 
 // Describe exports metric descriptions for Prometheus
 func (m *tracerouteExporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- successDesc
-	ch <- hopDesc
-	ch <- rttDesc
+	ch <- m.successDesc
+	ch <- m.hopDesc
+	ch <- m.unresponsiveDesc
+	ch <- m.lateDesc
+	ch <- m.reachedDesc
+	ch <- m.lastHopDesc
+	ch <- m.icmpErrorDesc
+	ch <- m.pathIDDesc
+	ch <- m.rttDesc
+
+	if m.asnResolver != nil {
+		ch <- m.asPathDesc
+		ch <- m.asPathLenDesc
+		ch <- m.penultimateDesc
+	}
+
+	if m.pathChanges {
+		ch <- m.pathChangesDesc
+	}
+
+	if m.hopCountries {
+		ch <- m.countryHopsDesc
+	}
+
+	if m.hopCities {
+		ch <- m.cityHopsDesc
+	}
+
+	if m.hopRTT {
+		ch <- m.hopRttDesc
+	}
+
+	if m.hopLoss {
+		ch <- m.hopLossDesc
+	}
+
+	if m.mpls {
+		ch <- m.mplsDepthDesc
+		ch <- m.mplsLabelDesc
+	}
+
+	if m.upDesc != nil {
+		ch <- m.upDesc
+	}
 }
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/config"
+	"github.com/czerwonk/atlas_exporter/probe"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResult(t *testing.T, probeID int, hops string) *measurement.Result {
+	return testParisResult(t, probeID, 0, hops)
+}
+
+func testParisResult(t *testing.T, probeID, parisID int, hops string) *measurement.Result {
+	res := &measurement.Result{}
+	err := json.Unmarshal([]byte(`{
+		"type": "traceroute",
+		"prb_id": `+strconv.Itoa(probeID)+`,
+		"msm_id": 1,
+		"paris_id": `+strconv.Itoa(parisID)+`,
+		"af": 4,
+		"proto": "ICMP",
+		"dst_addr": "8.8.8.8",
+		"dst_name": "8.8.8.8",
+		"result": `+hops+`
+	}`), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestDestinationReached(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{})
+	m.Add(testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+	m.Add(testResult(t, 2, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"x": "*"}, {"x": "*"}]}
+	]`), &probe.Probe{ID: 2, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_traceroute_destination_reached Last hop answered from the destination address (not affected by failure_threshold)
+# TYPE atlas_traceroute_destination_reached gauge
+atlas_traceroute_destination_reached{asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",ip_version="4",lat="",long="",measurement="1",measurement_type="traceroute",probe="1",protocol="ICMP"} 1
+atlas_traceroute_destination_reached{asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",ip_version="4",lat="",long="",measurement="1",measurement_type="traceroute",probe="2",protocol="ICMP"} 0
+# HELP atlas_traceroute_last_responding_hop Last hop with a reply if the destination was not reached (value is the hop index)
+# TYPE atlas_traceroute_last_responding_hop gauge
+atlas_traceroute_last_responding_hop{asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",hop="1",hop_addr="192.168.1.1",ip_version="4",lat="",long="",measurement="1",measurement_type="traceroute",probe="2",protocol="ICMP"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_traceroute_destination_reached", "atlas_traceroute_last_responding_hop")
+	assert.NoError(t, err)
+}
+
+func TestParisIDLabel(t *testing.T) {
+	m := NewMeasurement("1", "4", &config.Config{Traceroute: config.TracerouteConfig{ParisIDLabel: true}})
+	m.Add(testParisResult(t, 1, 5, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+
+	expected := `
+# HELP atlas_traceroute_hops Number of hops
+# TYPE atlas_traceroute_hops gauge
+atlas_traceroute_hops{asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",ip_version="4",lat="",long="",measurement="1",measurement_type="traceroute",paris_id="5",probe="1",protocol="ICMP"} 2
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_traceroute_hops")
+	assert.NoError(t, err)
+}
+
+func TestHopsHistogram(t *testing.T) {
+	expected := `
+# HELP atlas_traceroute_hops_hist Histogram of hop counts over all traceroute requests
+# TYPE atlas_traceroute_hops_hist histogram
+atlas_traceroute_hops_hist_bucket{ip_version="4",measurement="1",measurement_type="traceroute",le="2"} 1
+atlas_traceroute_hops_hist_bucket{ip_version="4",measurement="1",measurement_type="traceroute",le="5"} 2
+atlas_traceroute_hops_hist_bucket{ip_version="4",measurement="1",measurement_type="traceroute",le="+Inf"} 2
+atlas_traceroute_hops_hist_sum{ip_version="4",measurement="1",measurement_type="traceroute"} 5
+atlas_traceroute_hops_hist_count{ip_version="4",measurement="1",measurement_type="traceroute"} 2
+`
+
+	m := NewMeasurement("1", "4", &config.Config{HistogramBuckets: config.HistogramBuckets{Traceroute: config.RttHistogramBucket{Hops: []float64{2, 5}}}})
+	m.Add(testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`), &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"})
+	m.Add(testResult(t, 2, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}]},
+		{"hop": 3, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`), &probe.Probe{ID: 2, Asn4: 64496, CountryCode: "DE"})
+
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_traceroute_hops_hist")
+	assert.NoError(t, err)
+}
+
+func TestPathChangesLabels(t *testing.T) {
+	result := func(ts int, hop string) *measurement.Result {
+		res := &measurement.Result{}
+		err := json.Unmarshal([]byte(`{
+			"fw": 5080,
+			"lts": 12,
+			"type": "traceroute",
+			"prb_id": 1,
+			"msm_id": 1554,
+			"paris_id": 1,
+			"af": 4,
+			"proto": "ICMP",
+			"dst_addr": "8.8.8.8",
+			"dst_name": "8.8.8.8",
+			"src_addr": "192.168.1.10",
+			"from": "198.51.100.10",
+			"timestamp": `+strconv.Itoa(ts)+`,
+			"endtime": `+strconv.Itoa(ts+2)+`,
+			"size": 48,
+			"result": [
+				{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1, "size": 76, "ttl": 64}]},
+				{"hop": 2, "result": [{"from": "`+hop+`", "rtt": 5.2, "size": 76, "ttl": 254}]},
+				{"hop": 3, "result": [{"from": "8.8.8.8", "rtt": 9.4, "size": 48, "ttl": 120}]}
+			]
+		}`), res)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	cfg := &config.Config{DropLabels: []string{"lat", "long"}, Traceroute: config.TracerouteConfig{PathChanges: true}}
+	m := NewMeasurement("1554", "4", cfg)
+	p := &probe.Probe{ID: 1, Asn4: 64496, CountryCode: "DE"}
+
+	m.Add(result(1700000000, "198.51.100.1"), p)
+	testutil.CollectAndCount(m)
+	m.Add(result(1700000900, "198.51.100.2"), p)
+
+	expected := `
+# HELP atlas_traceroute_path_changes_total Number of changes of the sequence of hop addresses observed for the probe
+# TYPE atlas_traceroute_path_changes_total counter
+atlas_traceroute_path_changes_total{asn="64496",country_code="DE",dst_addr="8.8.8.8",dst_name="8.8.8.8",ip_version="4",measurement="1554",measurement_type="traceroute",probe="1",protocol="ICMP"} 1
+`
+	err := testutil.CollectAndCompare(m, strings.NewReader(expected), "atlas_traceroute_path_changes_total")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"errors"
+	"net"
+	"sort"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/asn"
+	"github.com/czerwonk/atlas_exporter/geo"
+	"github.com/czerwonk/atlas_exporter/lookup"
+	log "github.com/sirupsen/logrus"
+)
+
+type locationHops struct {
+	location geo.Location
+	hops     int
+}
+
+// hopLocations returns the location of each hop. The location of a hop is the one of its first located public address,
+// hops not responding or not located are omitted. ok is false if the lookup of a hop address has not completed yet.
+func hopLocations(res *measurement.Result, r geo.Resolver) (locations []geo.Location, ok bool) {
+	locations = make([]geo.Location, 0)
+	for _, hop := range res.TracerouteResults() {
+		for _, rep := range hop.Replies() {
+			ip := net.ParseIP(rep.From())
+			if ip == nil || !asn.IsPublic(ip) {
+				continue
+			}
+
+			l, err := r.Locate(ip)
+			if errors.Is(err, lookup.ErrPending) {
+				return nil, false
+			}
+
+			if err != nil {
+				log.Debugf("could not resolve location of %s: %v", ip, err)
+				continue
+			}
+
+			if l.Country != "" {
+				locations = append(locations, l)
+				break
+			}
+		}
+	}
+
+	return locations, true
+}
+
+// countHops returns the number of hops per country or per city (sorted by country and city).
+// Hops not located on city level are omitted when counting by city.
+func countHops(locations []geo.Location, byCity bool) []locationHops {
+	counts := make(map[geo.Location]int)
+	for _, l := range locations {
+		if !byCity {
+			l.City = ""
+		} else if l.City == "" {
+			continue
+		}
+
+		counts[l]++
+	}
+
+	hops := make([]locationHops, 0, len(counts))
+	for l, count := range counts {
+		hops = append(hops, locationHops{location: l, hops: count})
+	}
+	sort.Slice(hops, func(i, j int) bool {
+		if hops[i].location.Country != hops[j].location.Country {
+			return hops[i].location.Country < hops[j].location.Country
+		}
+
+		return hops[i].location.City < hops[j].location.City
+	})
+
+	return hops
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"net"
+	"testing"
+
+	"github.com/czerwonk/atlas_exporter/geo"
+	"github.com/czerwonk/atlas_exporter/lookup"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticGeoResolver map[string]geo.Location
+
+func (r staticGeoResolver) Locate(ip net.IP) (geo.Location, error) {
+	if ip.String() == "192.0.2.1" {
+		return geo.Location{}, lookup.ErrPending
+	}
+
+	return r[ip.String()], nil
+}
+
+func TestHopLocations(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}, {"from": "198.51.100.1", "rtt": 5.3}]},
+		{"hop": 3, "result": [{"from": "198.51.100.2", "rtt": 6.3}]},
+		{"hop": 4, "result": [{"x": "*"}]},
+		{"hop": 5, "result": [{"from": "203.0.113.1", "rtt": 98.1}]},
+		{"hop": 6, "result": [{"from": "8.8.8.8", "rtt": 99.4}]}
+	]`)
+
+	r := staticGeoResolver{
+		"198.51.100.1": {Country: "DE", City: "Frankfurt am Main"},
+		"198.51.100.2": {Country: "DE"},
+		"203.0.113.1":  {Country: "US", City: "Ashburn"},
+	}
+
+	locations, ok := hopLocations(res, r)
+	assert.True(t, ok)
+
+	expected := []locationHops{
+		{location: geo.Location{Country: "DE"}, hops: 2},
+		{location: geo.Location{Country: "US"}, hops: 1},
+	}
+	assert.Equal(t, expected, countHops(locations, false))
+
+	expected = []locationHops{
+		{location: geo.Location{Country: "DE", City: "Frankfurt am Main"}, hops: 1},
+		{location: geo.Location{Country: "US", City: "Ashburn"}, hops: 1},
+	}
+	assert.Equal(t, expected, countHops(locations, true))
+}
+
+func TestHopLocationsPending(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "198.51.100.1", "rtt": 5.2}]},
+		{"hop": 2, "result": [{"from": "192.0.2.1", "rtt": 6.3}]}
+	]`)
+
+	_, ok := hopLocations(res, staticGeoResolver{"198.51.100.1": {Country: "DE"}})
+	assert.False(t, ok)
+}
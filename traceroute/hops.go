@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import "github.com/DNS-OARC/ripeatlas/measurement"
+
+type hopRTT struct {
+	hop  int
+	addr string
+	rtt  float64
+}
+
+type hopLoss struct {
+	hop   int
+	ratio float64
+}
+
+// unresponsiveHops returns the number of hops without any reply
+func unresponsiveHops(res *measurement.Result) int {
+	var count int
+	for _, hop := range res.TracerouteResults() {
+		responded := false
+		for _, rep := range hop.Replies() {
+			if rep.From() != "" {
+				responded = true
+				break
+			}
+		}
+
+		if !responded {
+			count++
+		}
+	}
+
+	return count
+}
+
+// lateReplies returns the number of replies received after the reply to a later packet was received
+func lateReplies(res *measurement.Result) int {
+	var count int
+	for _, hop := range res.TracerouteResults() {
+		for _, rep := range hop.Replies() {
+			if rep.Late() > 0 {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// lastRespondingHop returns the index and the first responding address of the last hop with a reply
+func lastRespondingHop(res *measurement.Result) (int, string, bool) {
+	hops := res.TracerouteResults()
+	for i := len(hops) - 1; i >= 0; i-- {
+		for _, rep := range hops[i].Replies() {
+			if rep.From() != "" {
+				return hops[i].Hop(), rep.From(), true
+			}
+		}
+	}
+
+	return 0, "", false
+}
+
+// hopLossRatios returns the ratio of packets without reply (timeouts) per hop
+func hopLossRatios(res *measurement.Result) []hopLoss {
+	ratios := make([]hopLoss, 0, len(res.TracerouteResults()))
+	for _, hop := range res.TracerouteResults() {
+		replies := hop.Replies()
+		if len(replies) == 0 {
+			continue
+		}
+
+		var lost int
+		for _, rep := range replies {
+			if rep.X() == noResponse {
+				lost++
+			}
+		}
+
+		ratios = append(ratios, hopLoss{hop: hop.Hop(), ratio: float64(lost) / float64(len(replies))})
+	}
+
+	return ratios
+}
+
+// hopRTTs returns the minimum RTT per hop and responding address (in order of the hops).
+// Hops not responding are omitted.
+func hopRTTs(res *measurement.Result) []hopRTT {
+	rtts := make([]hopRTT, 0)
+	for _, hop := range res.TracerouteResults() {
+		idx := make(map[string]int)
+		for _, rep := range hop.Replies() {
+			if rep.From() == "" || rep.Rtt() <= 0 {
+				continue
+			}
+
+			if i, found := idx[rep.From()]; found {
+				if rep.Rtt() < rtts[i].rtt {
+					rtts[i].rtt = rep.Rtt()
+				}
+				continue
+			}
+
+			idx[rep.From()] = len(rtts)
+			rtts = append(rtts, hopRTT{hop: hop.Hop(), addr: rep.From(), rtt: rep.Rtt()})
+		}
+	}
+
+	return rtts
+}
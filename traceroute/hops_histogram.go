@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type hopsHistogram struct {
+	hops prometheus.Histogram
+}
+
+func newHopsHistogram(id, ipVersion string, buckets []float64) exporter.Histogram {
+	if buckets == nil {
+		buckets = []float64{5, 10, 15, 20, 30}
+	}
+
+	return &hopsHistogram{
+		hops: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: ns,
+			Subsystem: sub,
+			Name:      "hops_hist",
+			Buckets:   buckets,
+			Help:      "Histogram of hop counts over all traceroute requests",
+			ConstLabels: prometheus.Labels{
+				"measurement":      id,
+				"ip_version":       ipVersion,
+				"measurement_type": sub,
+			},
+		}),
+	}
+}
+
+func (h *hopsHistogram) ProcessResult(r *measurement.Result) {
+	if hops := len(r.TracerouteResults()); hops > 0 {
+		h.hops.Observe(float64(hops))
+	}
+}
+
+func (h *hopsHistogram) Hist() prometheus.Histogram {
+	return h.hops
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHopRTTs(t *testing.T) {
+	res := &measurement.Result{}
+	err := json.Unmarshal([]byte(`{
+		"type": "traceroute",
+		"af": 4,
+		"dst_addr": "8.8.8.8",
+		"result": [
+			{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.3}, {"from": "192.168.1.1", "rtt": 1.1}]},
+			{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}, {"from": "198.51.100.5", "rtt": 5.9}]},
+			{"hop": 3, "result": [{"x": "*"}, {"x": "*"}]},
+			{"hop": 4, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+		]
+	}`), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []hopRTT{
+		{hop: 1, addr: "192.168.1.1", rtt: 1.1},
+		{hop: 2, addr: "198.51.100.1", rtt: 5.2},
+		{hop: 2, addr: "198.51.100.5", rtt: 5.9},
+		{hop: 4, addr: "8.8.8.8", rtt: 9.4},
+	}
+	assert.Equal(t, expected, hopRTTs(res))
+}
+
+func TestUnresponsiveHops(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"x": "*"}, {"x": "*"}, {"x": "*"}]},
+		{"hop": 3, "result": [{"x": "*"}, {"from": "198.51.100.2", "rtt": 6.3}, {"x": "*"}]},
+		{"hop": 4, "result": [{"x": "*"}, {"x": "*"}, {"x": "*"}]},
+		{"hop": 5, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+
+	assert.Equal(t, 2, unresponsiveHops(res))
+}
+
+func TestLateReplies(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}, {"from": "192.168.1.1", "late": 1}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "late": 2}, {"from": "198.51.100.1", "rtt": 5.3}]}
+	]`)
+
+	assert.Equal(t, 2, lateReplies(res))
+}
+
+func TestLastRespondingHop(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"x": "*"}, {"from": "198.51.100.2", "rtt": 6.3}]},
+		{"hop": 3, "result": [{"x": "*"}, {"x": "*"}, {"x": "*"}]}
+	]`)
+
+	hop, addr, ok := lastRespondingHop(res)
+	assert.True(t, ok)
+	assert.Equal(t, 2, hop)
+	assert.Equal(t, "198.51.100.2", addr)
+
+	_, _, ok = lastRespondingHop(testResult(t, 1, `[{"hop": 1, "result": [{"x": "*"}]}]`))
+	assert.False(t, ok)
+}
+
+func TestHopLossRatios(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.3}, {"from": "192.168.1.1", "rtt": 1.1}, {"from": "192.168.1.1", "rtt": 1.2}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}, {"x": "*"}, {"from": "198.51.100.1", "rtt": 5.9}]},
+		{"hop": 3, "result": [{"x": "*"}, {"x": "*"}, {"x": "*"}]}
+	]`)
+
+	expected := []hopLoss{
+		{hop: 1, ratio: 0},
+		{hop: 2, ratio: 1.0 / 3},
+		{hop: 3, ratio: 1},
+	}
+	assert.Equal(t, expected, hopLossRatios(res))
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"sort"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+// icmpErrorNames maps the error codes reported by the probes for ICMP unreachable replies to names
+var icmpErrorNames = map[string]string{
+	"N": "network_unreachable",
+	"H": "host_unreachable",
+	"A": "admin_prohibited",
+	"P": "protocol_unreachable",
+	"p": "port_unreachable",
+	"h": "beyond_scope",
+}
+
+type icmpErrorCount struct {
+	name  string
+	count int
+}
+
+// icmpErrors returns the number of replies per ICMP error (sorted by name). Unknown codes are counted as "other".
+func icmpErrors(res *measurement.Result) []icmpErrorCount {
+	counts := make(map[string]int)
+	for _, hop := range res.TracerouteResults() {
+		for _, rep := range hop.Replies() {
+			if rep.Err() == "" {
+				continue
+			}
+
+			name, found := icmpErrorNames[rep.Err()]
+			if !found {
+				name = "other"
+			}
+			counts[name]++
+		}
+	}
+
+	errs := make([]icmpErrorCount, 0, len(counts))
+	for name, count := range counts {
+		errs = append(errs, icmpErrorCount{name: name, count: count})
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].name < errs[j].name
+	})
+
+	return errs
+}
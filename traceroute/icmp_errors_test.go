@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICMPErrors(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2, "err": "A"}, {"from": "198.51.100.1", "rtt": 5.3, "err": "A"}]},
+		{"hop": 3, "result": [{"from": "198.51.100.2", "rtt": 6.3, "err": "H"}, {"from": "198.51.100.2", "rtt": 6.1, "err": "X"}]}
+	]`)
+
+	expected := []icmpErrorCount{
+		{name: "admin_prohibited", count: 2},
+		{name: "host_unreachable", count: 1},
+		{name: "other", count: 1},
+	}
+	assert.Equal(t, expected, icmpErrors(res))
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"github.com/DNS-OARC/ripeatlas/measurement"
+	rtraceroute "github.com/DNS-OARC/ripeatlas/measurement/traceroute"
+)
+
+// ICMP extension class and type of MPLS label stack objects (RFC 4950)
+const (
+	mplsClass = 1
+	mplsType  = 1
+)
+
+type hopMPLS struct {
+	hop      int
+	addr     string
+	depth    int
+	topLabel int
+}
+
+// hopMPLSStacks returns the MPLS label stack depth and top label per hop and responding address
+// using the ICMP extensions of the first reply of the address containing a label stack.
+func hopMPLSStacks(res *measurement.Result) []hopMPLS {
+	stacks := make([]hopMPLS, 0)
+	for _, hop := range res.TracerouteResults() {
+		seen := make(map[string]bool)
+		for _, rep := range hop.Replies() {
+			if rep.From() == "" || seen[rep.From()] {
+				continue
+			}
+
+			labels := mplsLabels(rep.Icmpext())
+			if len(labels) == 0 {
+				continue
+			}
+
+			seen[rep.From()] = true
+			stacks = append(stacks, hopMPLS{hop: hop.Hop(), addr: rep.From(), depth: len(labels), topLabel: labels[0]})
+		}
+	}
+
+	return stacks
+}
+
+// mplsLabels returns the labels of the MPLS label stack (top first). The ripeatlas bindings do not
+// decode the extension objects, so they are extracted from the raw JSON structure.
+func mplsLabels(ext *rtraceroute.Icmpext) []int {
+	if ext == nil {
+		return nil
+	}
+
+	for _, o := range ext.Objects() {
+		obj, ok := o.(map[string]interface{})
+		if !ok || intValue(obj["class"]) != mplsClass || intValue(obj["type"]) != mplsType {
+			continue
+		}
+
+		entries, ok := obj["mpls"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		labels := make([]int, 0, len(entries))
+		for _, e := range entries {
+			if entry, ok := e.(map[string]interface{}); ok {
+				labels = append(labels, intValue(entry["label"]))
+			}
+		}
+
+		return labels
+	}
+
+	return nil
+}
+
+func intValue(v interface{}) int {
+	f, ok := v.(float64)
+	if !ok {
+		return -1
+	}
+
+	return int(f)
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHopMPLSStacks(t *testing.T) {
+	res := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [
+			{"from": "198.51.100.1", "rtt": 5.2, "icmpext": {"version": 2, "rfc4884": 1, "obj": [{"class": 1, "type": 1, "mpls": [
+				{"exp": 0, "label": 24015, "s": 0, "ttl": 1},
+				{"exp": 0, "label": 16, "s": 1, "ttl": 1}
+			]}]}},
+			{"from": "198.51.100.1", "rtt": 5.4, "icmpext": {"version": 2, "rfc4884": 1, "obj": [{"class": 1, "type": 1, "mpls": [
+				{"exp": 0, "label": 24015, "s": 0, "ttl": 1},
+				{"exp": 0, "label": 16, "s": 1, "ttl": 1}
+			]}]}}
+		]},
+		{"hop": 3, "result": [{"from": "198.51.100.2", "rtt": 6.3, "icmpext": {"version": 2, "rfc4884": 1, "obj": [{"class": 2, "type": 1}]}}]},
+		{"hop": 4, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+
+	expected := []hopMPLS{
+		{hop: 2, addr: "198.51.100.1", depth: 2, topLabel: 24015},
+	}
+	assert.Equal(t, expected, hopMPLSStacks(res))
+}
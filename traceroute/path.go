@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/DNS-OARC/ripeatlas/measurement"
+)
+
+const noResponse = "*"
+
+// pathID returns a FNV-1a hash of the sequence of hop addresses. Addresses of the same hop are sorted
+// (load balanced paths are stable), hops not responding are represented by a placeholder.
+func pathID(res *measurement.Result) uint32 {
+	hops := make([]string, 0, len(res.TracerouteResults()))
+	for _, hop := range res.TracerouteResults() {
+		addrs := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, rep := range hop.Replies() {
+			if rep.From() == "" || seen[rep.From()] {
+				continue
+			}
+
+			seen[rep.From()] = true
+			addrs = append(addrs, rep.From())
+		}
+
+		if len(addrs) == 0 {
+			hops = append(hops, noResponse)
+			continue
+		}
+
+		sort.Strings(addrs)
+		hops = append(hops, strings.Join(addrs, "|"))
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(hops, ",")))
+
+	return h.Sum32()
+}
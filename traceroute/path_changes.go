@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"time"
+
+	"github.com/czerwonk/atlas_exporter/exporter"
+)
+
+const pathStateTTL = 24 * time.Hour
+
+var paths = exporter.NewStateStore[pathState](pathStateTTL)
+
+type pathState struct {
+	timestamp int
+	pathID    uint32
+	changes   int
+}
+
+// pathChanges returns the number of changes of the path observed for the key
+func pathChanges(key string, timestamp int, pathID uint32) int {
+	s := paths.Update(key, func(s pathState, found bool) pathState {
+		if found && s.timestamp == timestamp {
+			return s
+		}
+
+		if found && s.pathID != pathID {
+			s.changes++
+		}
+		s.timestamp = timestamp
+		s.pathID = pathID
+
+		return s
+	})
+
+	return s.changes
+}
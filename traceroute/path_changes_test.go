@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import "testing"
+
+func TestPathChanges(t *testing.T) {
+	steps := []struct {
+		timestamp int
+		pathID    uint32
+		expected  int
+	}{
+		{timestamp: 1, pathID: 1, expected: 0},
+		{timestamp: 2, pathID: 1, expected: 0},
+		{timestamp: 3, pathID: 2, expected: 1},
+		{timestamp: 3, pathID: 2, expected: 1},
+		{timestamp: 4, pathID: 1, expected: 2},
+	}
+
+	for i, s := range steps {
+		if got := pathChanges("test/1", s.timestamp, s.pathID); got != s.expected {
+			t.Fatalf("step %d: expected %d, got %d", i, s.expected, got)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package traceroute
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathID(t *testing.T) {
+	path := testResult(t, 1, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "198.51.100.1", "rtt": 5.2}, {"from": "198.51.100.5", "rtt": 5.9}]},
+		{"hop": 3, "result": [{"x": "*"}]},
+		{"hop": 4, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+	samePath := testResult(t, 2, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.4}, {"from": "192.168.1.1", "rtt": 1.2}]},
+		{"hop": 2, "result": [{"from": "198.51.100.5", "rtt": 6.1}, {"from": "198.51.100.1", "rtt": 5.0}]},
+		{"hop": 3, "result": [{"x": "*"}, {"x": "*"}]},
+		{"hop": 4, "result": [{"from": "8.8.8.8", "rtt": 9.9}]}
+	]`)
+	rerouted := testResult(t, 3, `[
+		{"hop": 1, "result": [{"from": "192.168.1.1", "rtt": 1.1}]},
+		{"hop": 2, "result": [{"from": "203.0.113.1", "rtt": 5.2}]},
+		{"hop": 3, "result": [{"x": "*"}]},
+		{"hop": 4, "result": [{"from": "8.8.8.8", "rtt": 9.4}]}
+	]`)
+
+	assert.Equal(t, pathID(path), pathID(samePath))
+	assert.NotEqual(t, pathID(path), pathID(rerouted))
+}
@@ -25,8 +25,9 @@ func newRttHistogram(id, ipVersion string, buckets []float64) exporter.Histogram
 			Buckets:   buckets,
 			Help:      "Histogram of round trip times over all traceroute requests",
 			ConstLabels: prometheus.Labels{
-				"measurement": id,
-				"ip_version":  ipVersion,
+				"measurement":      id,
+				"ip_version":       ipVersion,
+				"measurement_type": sub,
 			},
 		}),
 	}
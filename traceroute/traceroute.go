@@ -3,27 +3,77 @@
 package traceroute
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/DNS-OARC/ripeatlas/measurement"
+	"github.com/czerwonk/atlas_exporter/asn"
 	"github.com/czerwonk/atlas_exporter/config"
 	"github.com/czerwonk/atlas_exporter/exporter"
+	"github.com/czerwonk/atlas_exporter/geo"
 )
 
 const (
-	ns  = "atlas"
-	sub = "traceroute"
+	ns          = "atlas"
+	sub         = "traceroute"
+	asnCacheTTL = 24 * time.Hour
+	geoCacheTTL = 24 * time.Hour
+)
+
+var (
+	asnResolver = asn.NewAsyncResolver(asn.NewRIPEstatResolver(), asnCacheTTL)
+	geoResolver = geo.NewAsyncResolver(geo.NewRIPEstatResolver(), geoCacheTTL)
 )
 
+// InitResolvers sets up the resolvers used to enrich the hops as configured (the RIPEstat Data API is used by default)
+func InitResolvers(cfg *config.Config) error {
+	if cfg.Traceroute.ASNDatabase != "" {
+		r, err := asn.NewMaxMindResolver(cfg.Traceroute.ASNDatabase)
+		if err != nil {
+			return fmt.Errorf("could not open ASN database: %v", err)
+		}
+		asnResolver = r
+	}
+
+	if cfg.Traceroute.GeoDatabase != "" {
+		r, err := geo.NewMaxMindResolver(cfg.Traceroute.GeoDatabase)
+		if err != nil {
+			return fmt.Errorf("could not open geolocation database: %v", err)
+		}
+		geoResolver = r
+	}
+
+	return nil
+}
+
 // NewMeasurement returns a new instance of `exorter.Measurement` for a traceroute measurement
 func NewMeasurement(id, ipVersion string, cfg *config.Config) *exporter.Measurement {
 	opts := []exporter.MeasurementOpt{
-		exporter.WithHistograms(newRttHistogram(id, ipVersion, cfg.HistogramBuckets.Traceroute.Rtt)),
+		exporter.WithHistograms(
+			newRttHistogram(id, ipVersion, cfg.HistogramBuckets.Traceroute.Rtt),
+			newHopsHistogram(id, ipVersion, cfg.HistogramBuckets.Traceroute.Hops),
+		),
 	}
 
 	if cfg.FilterInvalidResults {
 		opts = append(opts, exporter.WithValidator(&tracerouteResultValidator{}))
 	}
 
-	return exporter.NewMeasurement(&tracerouteExporter{id}, opts...)
+	if cfg.MinFirmware > 0 {
+		opts = append(opts, exporter.WithMinFirmware(id, sub, cfg.MinFirmware))
+	}
+
+	e := newTracerouteExporter(id, cfg)
+
+	if cfg.Traceroute.ASPath {
+		e.asnResolver = asnResolver
+	}
+
+	if cfg.Traceroute.HopCountries || cfg.Traceroute.HopCities {
+		e.geoResolver = geoResolver
+	}
+
+	return exporter.NewMeasurement(e, opts...)
 }
 
 func processLastHop(r *measurement.Result) (success float64, rtt float64) {
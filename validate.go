@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/czerwonk/atlas_exporter/atlas"
+	"github.com/czerwonk/atlas_exporter/traceroute"
+)
+
+// checkConfig validates the config and sets up what is shared by all measurements (e.g. opens the configured databases)
+func checkConfig() []error {
+	errs := cfg.Validate()
+	if err := traceroute.InitResolvers(cfg); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+func validateConfig() int {
+	errs := checkConfig()
+	if len(errs) == 0 {
+		errs = atlas.ValidateMeasurements(cfg)
+	}
+
+	if len(errs) > 0 {
+		fmt.Printf("Configuration is invalid (%d errors):\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
+		}
+
+		return 1
+	}
+
+	fmt.Printf("Configuration is valid (%d measurements)\n", len(cfg.Measurements))
+	return 0
+}